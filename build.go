@@ -0,0 +1,82 @@
+package resource
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BuildMetadata represents the Concourse build which runs the step.
+// Concourse provides it as the environment variables for get and put steps.
+type BuildMetadata struct {
+	ID           string
+	Name         string
+	JobName      string
+	PipelineName string
+	// PipelineInstanceVars is the JSON object of the vars of instanced pipelines
+	PipelineInstanceVars string
+	TeamName             string
+	ExternalURL          string
+}
+
+// BuildMetadataFromEnv returns the build metadata in the environment variables
+func BuildMetadataFromEnv(getenv func(string) string) BuildMetadata {
+	return BuildMetadata{
+		ID:                   getenv("BUILD_ID"),
+		Name:                 getenv("BUILD_NAME"),
+		JobName:              getenv("BUILD_JOB_NAME"),
+		PipelineName:         getenv("BUILD_PIPELINE_NAME"),
+		PipelineInstanceVars: getenv("BUILD_PIPELINE_INSTANCE_VARS"),
+		TeamName:             getenv("BUILD_TEAM_NAME"),
+		ExternalURL:          getenv("ATC_EXTERNAL_URL"),
+	}
+}
+
+// Env returns the build metadata as the environment variables
+func (b BuildMetadata) Env() map[string]string {
+	env := map[string]string{}
+	for k, v := range map[string]string{
+		"BUILD_ID":                     b.ID,
+		"BUILD_NAME":                   b.Name,
+		"BUILD_JOB_NAME":               b.JobName,
+		"BUILD_PIPELINE_NAME":          b.PipelineName,
+		"BUILD_PIPELINE_INSTANCE_VARS": b.PipelineInstanceVars,
+		"BUILD_TEAM_NAME":              b.TeamName,
+		"ATC_EXTERNAL_URL":             b.ExternalURL,
+	} {
+		if v != "" {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// Job returns the job as "team/pipeline/job", or empty for one-off builds
+func (b BuildMetadata) Job() string {
+	if b.TeamName == "" || b.PipelineName == "" || b.JobName == "" {
+		return ""
+	}
+	return strings.Join([]string{b.TeamName, b.PipelineName, b.JobName}, "/")
+}
+
+// URL returns the URL of the build in the web UI, or empty if it is unknown
+func (b BuildMetadata) URL() string {
+	if b.ExternalURL == "" {
+		return ""
+	}
+	base := strings.TrimRight(b.ExternalURL, "/")
+
+	if b.Job() == "" || b.Name == "" {
+		if b.ID == "" {
+			return ""
+		}
+		return base + "/builds/" + url.PathEscape(b.ID)
+	}
+	u := base + "/teams/" + url.PathEscape(b.TeamName) +
+		"/pipelines/" + url.PathEscape(b.PipelineName) +
+		"/jobs/" + url.PathEscape(b.JobName) +
+		"/builds/" + url.PathEscape(b.Name)
+	if b.PipelineInstanceVars != "" {
+		u += "?vars=" + url.QueryEscape(b.PipelineInstanceVars)
+	}
+	return u
+}
@@ -0,0 +1,48 @@
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+var _ = Describe("BuildMetadata", func() {
+	var env map[string]string
+
+	BeforeEach(func() {
+		env = map[string]string{
+			"BUILD_ID":            "77",
+			"BUILD_NAME":          "12",
+			"BUILD_JOB_NAME":      "release",
+			"BUILD_PIPELINE_NAME": "web",
+			"BUILD_TEAM_NAME":     "main",
+			"ATC_EXTERNAL_URL":    "https://ci.example.com/",
+		}
+	})
+
+	build := func() resource.BuildMetadata {
+		return resource.BuildMetadataFromEnv(func(k string) string { return env[k] })
+	}
+
+	It("links to the build of the job", func() {
+		Expect(build().URL()).To(Equal("https://ci.example.com/teams/main/pipelines/web/jobs/release/builds/12"))
+		Expect(build().Job()).To(Equal("main/web/release"))
+	})
+
+	It("links to the build of the instanced pipeline with its vars", func() {
+		env["BUILD_PIPELINE_INSTANCE_VARS"] = `{"branch":"feature"}`
+		Expect(build().URL()).To(HaveSuffix(`/builds/12?vars=%7B%22branch%22%3A%22feature%22%7D`))
+	})
+
+	It("links to the one-off build by its id", func() {
+		delete(env, "BUILD_JOB_NAME")
+		Expect(build().URL()).To(Equal("https://ci.example.com/builds/77"))
+	})
+
+	It("does not link outside of Concourse", func() {
+		env = map[string]string{}
+		Expect(build().URL()).To(BeEmpty())
+		Expect(build().Env()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,304 @@
+package resource
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calverToken is the element of calver_format
+type calverToken struct {
+	// name is the placeholder such as YYYY, or empty for the literal
+	name    string
+	literal string
+}
+
+// calverPlaceholders are the placeholders of calver_format, longer ones first.
+// They follow https://calver.org, and the years of YY and 0Y are since 2000.
+var calverPlaceholders = []string{"YYYY", "MICRO", "YY", "0Y", "MM", "0M", "DD", "0D"}
+
+// calverWidths are the widths of the zero-padded placeholders
+var calverWidths = map[string]int{"YYYY": 4, "0Y": 2, "0M": 2, "0D": 2}
+
+// calver is the value of the calendar-based version
+type calver struct {
+	year, month, day, micro int
+}
+
+// CalVer is the scheme of the calendar-based versions.
+// The bump moves the version to the current period with MICRO reset to 0,
+// or increments MICRO within the same period. Without MICRO, a single version is allowed per period.
+type CalVer struct {
+	format string
+	tokens []calverToken
+	re     *regexp.Regexp
+	now    func() time.Time
+}
+
+// NewCalVer returns the calver scheme of the format, now returns the current time
+func NewCalVer(format string, now func() time.Time) (*CalVer, error) {
+	if format == "" {
+		format = DefaultCalVerFormat
+	}
+	tokens, err := parseCalVerFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, t := range tokens {
+		switch {
+		case t.name == "":
+			pattern.WriteString(regexp.QuoteMeta(t.literal))
+		case calverWidths[t.name] > 0:
+			fmt.Fprintf(&pattern, `(\d{%d})`, calverWidths[t.name])
+		default:
+			pattern.WriteString(`(\d+)`)
+		}
+	}
+	pattern.WriteString("$")
+	return &CalVer{format: format, tokens: tokens, re: regexp.MustCompile(pattern.String()), now: now}, nil
+}
+
+func parseCalVerFormat(format string) ([]calverToken, error) {
+	var tokens []calverToken
+	seen := map[string]bool{}
+	for rest := format; rest != ""; {
+		name := ""
+		for _, p := range calverPlaceholders {
+			if strings.HasPrefix(rest, p) {
+				name = p
+				break
+			}
+		}
+		if name == "" {
+			if n := len(tokens); n > 0 && tokens[n-1].name == "" {
+				tokens[n-1].literal += rest[:1]
+			} else {
+				tokens = append(tokens, calverToken{literal: rest[:1]})
+			}
+			rest = rest[1:]
+			continue
+		}
+
+		unit := calverUnit(name)
+		if seen[unit] {
+			return nil, fmt.Errorf("calver_format must have a single placeholder of each unit: %q", format)
+		}
+		seen[unit] = true
+		// the width of the previous number must be fixed to split the digits
+		if n := len(tokens); n > 0 && tokens[n-1].name != "" && calverWidths[tokens[n-1].name] == 0 {
+			return nil, fmt.Errorf("calver_format must separate %s from the next placeholder: %q", tokens[n-1].name, format)
+		}
+		tokens = append(tokens, calverToken{name: name})
+		rest = rest[len(name):]
+	}
+	if !seen["year"] {
+		return nil, fmt.Errorf("calver_format must contain the year (YYYY, YY or 0Y): %q", format)
+	}
+	if seen["day"] && !seen["month"] {
+		return nil, fmt.Errorf("calver_format must contain the month (MM or 0M) with the day: %q", format)
+	}
+	return tokens, nil
+}
+
+// calverUnit returns the unit of the placeholder
+func calverUnit(name string) string {
+	switch name {
+	case "YYYY", "YY", "0Y":
+		return "year"
+	case "MM", "0M":
+		return "month"
+	case "DD", "0D":
+		return "day"
+	default:
+		return "micro"
+	}
+}
+
+// Parse returns the value of the version which matches calver_format
+func (c *CalVer) Parse(version string) (Value, error) {
+	return c.parse(version)
+}
+
+// Format returns the version of the value in calver_format
+func (c *CalVer) Format(v Value) string {
+	return c.render(v.(calver))
+}
+
+// Next returns the first value of the current period if the value is in the past period,
+// otherwise MICRO incremented within its period.
+// Without MICRO, it returns the error unless the value is in the past period.
+func (c *CalVer) Next(value Value) (Value, error) {
+	v := value.(calver)
+	now := c.now().UTC()
+	current := calver{year: now.Year()}
+	for _, t := range c.tokens {
+		switch calverUnit(t.name) {
+		case "month":
+			current.month = int(now.Month())
+		case "day":
+			current.day = now.Day()
+		}
+	}
+	if comparePeriods(v, current) < 0 {
+		return current, nil
+	}
+
+	// the version is in the current period, or ahead of the clock
+	if !c.hasMicro() {
+		return nil, fmt.Errorf("version %s is not in the past period, calver_format without MICRO allows a single version per period", c.render(v))
+	}
+	v.micro++
+	return v, nil
+}
+
+// Compare compares the periods, and then MICRO of the values
+func (c *CalVer) Compare(a, b Value) int {
+	x, y := a.(calver), b.(calver)
+	if p := comparePeriods(x, y); p != 0 {
+		return p
+	}
+	return compareInts(x.micro, y.micro)
+}
+
+// Initial returns the value of the year 0, which precedes all the other values
+func (c *CalVer) Initial() Value {
+	return calver{}
+}
+
+// hasMicro reports whether the format has MICRO
+func (c *CalVer) hasMicro() bool {
+	for _, t := range c.tokens {
+		if t.name == "MICRO" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CalVer) parse(version string) (calver, error) {
+	m := c.re.FindStringSubmatch(version)
+	if m == nil {
+		return calver{}, fmt.Errorf("invalid version %q: must match calver_format %q", version, c.format)
+	}
+	var v calver
+	// the initial version has zero in all the placeholders
+	initial := true
+	i := 1
+	for _, t := range c.tokens {
+		if t.name == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i])
+		if err != nil {
+			return calver{}, fmt.Errorf("invalid version %q: %v", version, err)
+		}
+		i++
+		if n != 0 && t.name != "MICRO" {
+			initial = false
+		}
+		switch t.name {
+		case "YYYY":
+			v.year = n
+		case "YY", "0Y":
+			v.year = 2000 + n
+		case "MM", "0M":
+			v.month = n
+		case "DD", "0D":
+			v.day = n
+		case "MICRO":
+			v.micro = n
+		}
+	}
+	if initial {
+		return calver{micro: v.micro}, nil
+	}
+	if err := c.validateDate(v); err != nil {
+		return calver{}, fmt.Errorf("invalid version %q: %v", version, err)
+	}
+	return v, nil
+}
+
+// validateDate returns the error if the month or the day of the value does not exist in the calendar
+func (c *CalVer) validateDate(v calver) error {
+	hasMonth, hasDay := false, false
+	for _, t := range c.tokens {
+		switch calverUnit(t.name) {
+		case "month":
+			hasMonth = true
+		case "day":
+			hasDay = true
+		}
+	}
+	if hasMonth && (v.month < 1 || v.month > 12) {
+		return fmt.Errorf("month must be between 1 and 12: %d", v.month)
+	}
+	if hasDay {
+		// the day 0 of the next month is the last day of the month
+		last := time.Date(v.year, time.Month(v.month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if v.day < 1 || v.day > last {
+			return fmt.Errorf("day must be between 1 and %d: %d", last, v.day)
+		}
+	}
+	return nil
+}
+
+func (c *CalVer) render(v calver) string {
+	var b strings.Builder
+	for _, t := range c.tokens {
+		switch t.name {
+		case "":
+			b.WriteString(t.literal)
+		case "YYYY":
+			fmt.Fprintf(&b, "%04d", v.year)
+		case "YY":
+			fmt.Fprintf(&b, "%d", yearSince2000(v.year))
+		case "0Y":
+			fmt.Fprintf(&b, "%02d", yearSince2000(v.year))
+		case "MM":
+			fmt.Fprintf(&b, "%d", v.month)
+		case "0M":
+			fmt.Fprintf(&b, "%02d", v.month)
+		case "DD":
+			fmt.Fprintf(&b, "%d", v.day)
+		case "0D":
+			fmt.Fprintf(&b, "%02d", v.day)
+		case "MICRO":
+			fmt.Fprintf(&b, "%d", v.micro)
+		}
+	}
+	return b.String()
+}
+
+// yearSince2000 returns the year of YY and 0Y, the year 0 is kept for the initial version
+func yearSince2000(year int) int {
+	if year < 2000 {
+		return 0
+	}
+	return year - 2000
+}
+
+func comparePeriods(a, b calver) int {
+	if c := compareInts(a.year, b.year); c != 0 {
+		return c
+	}
+	if c := compareInts(a.month, b.month); c != 0 {
+		return c
+	}
+	return compareInts(a.day, b.day)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
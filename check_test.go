@@ -47,17 +47,15 @@ var _ = Describe("Check", func() {
 
 	Context("when target file exists in github", func() {
 		BeforeEach(func() {
-			req.Source = resource.Source{
-				Driver: "git",
-
-				InitialVersion: "0",
-
-				URI:      githubURI,
-				Branch:   githubBranch,
-				Username: githubUsername,
-				Password: githubPassword,
-				File:     "version",
-			}
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             githubURI,
+				"branch":          githubBranch,
+				"username":        githubUsername,
+				"password":        githubPassword,
+				"file":            "version",
+			})
 		})
 
 		It("works", func() {
@@ -66,17 +64,15 @@ var _ = Describe("Check", func() {
 	})
 	Context("when target file does not exists in github", func() {
 		BeforeEach(func() {
-			req.Source = resource.Source{
-				Driver: "git",
-
-				InitialVersion: "0",
-
-				URI:      githubURI,
-				Branch:   githubBranch,
-				Username: githubUsername,
-				Password: githubPassword,
-				File:     "missingFile",
-			}
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             githubURI,
+				"branch":          githubBranch,
+				"username":        githubUsername,
+				"password":        githubPassword,
+				"file":            "missingFile",
+			})
 		})
 
 		It("works", func() {
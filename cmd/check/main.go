@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
 	resource "github.com/cappyzawa/romver-resource"
 	"github.com/cappyzawa/romver-resource/driver"
@@ -15,13 +17,22 @@ type Check struct {
 	InStream  io.Reader
 	ErrStream io.Writer
 	OutStream io.Writer
+
+	errorFormat string
+	redactor    *resource.Redactor
 }
 
-func (c *Check) Execute(args []string) int {
+func (c *Check) Execute(ctx context.Context, args []string) int {
 	var req resource.CheckRequest
 	if err := json.NewDecoder(c.InStream).Decode(&req); err != nil {
 		return c.fatal("decoding request", err)
 	}
+	c.errorFormat = req.Source.ErrorFormat
+	c.redactor = resource.NewRedactor(driver.Secrets(req.Source)...)
+
+	if err := driver.Validate(req.Source, nil); err != nil {
+		return c.fatal("validating request", err)
+	}
 
 	driver, err := driver.FromSource(req.Source)
 	if err != nil {
@@ -32,7 +43,7 @@ func (c *Check) Execute(args []string) int {
 		cursor = req.Version.Number
 	}
 
-	versions, err := driver.Check(cursor)
+	versions, err := driver.Check(ctx, cursor)
 	if err != nil {
 		return c.fatal("checking for new versions", err)
 	}
@@ -50,7 +61,7 @@ func (c *Check) Execute(args []string) int {
 }
 
 func (c *Check) fatal(doing string, err error) int {
-	fmt.Fprintf(c.ErrStream, "error %s: %v", doing, err)
+	driver.ReportError(c.ErrStream, c.redactor, c.errorFormat, doing, err)
 	return 1
 }
 
@@ -60,5 +71,9 @@ func main() {
 		ErrStream: os.Stderr,
 		OutStream: os.Stdout,
 	}
-	os.Exit(c.Execute(os.Args))
+	// Concourse sends SIGTERM when the build is aborted
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	code := c.Execute(ctx, os.Args)
+	stop()
+	os.Exit(code)
 }
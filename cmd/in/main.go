@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strconv"
+	"syscall"
+	"time"
 
 	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
 )
 
 // In represents in command stream
@@ -16,10 +21,14 @@ type In struct {
 	InStream  io.Reader
 	ErrStream io.Writer
 	OutStream io.Writer
+	Getenv    func(string) string
+
+	errorFormat string
+	redactor    *resource.Redactor
 }
 
 // Execute executes in command
-func (i *In) Execute(args []string) int {
+func (i *In) Execute(ctx context.Context, args []string) int {
 	if len(args) < 2 {
 		fmt.Fprintf(i.ErrStream, "usage: %s <destination>", args[0])
 		return 1
@@ -35,34 +44,123 @@ func (i *In) Execute(args []string) int {
 	if err := json.NewDecoder(i.InStream).Decode(&req); err != nil {
 		return i.fatal("decoding request", err)
 	}
+	i.errorFormat = req.Source.ErrorFormat
+	i.redactor = resource.NewRedactor(driver.Secrets(req.Source)...)
 
-	version := req.Version.Number
-	if req.Params.Bump {
-		versionInt, err := strconv.Atoi(version)
-		if err != nil {
-			return i.fatal("coverting version to int", err)
-		}
-		version = strconv.Itoa(versionInt + 1)
+	if err := driver.Validate(req.Source, req.Params); err != nil {
+		return i.fatal("validating request", err)
 	}
 
-	versionFileNames := []string{"number", "version"}
-	for _, fileName := range versionFileNames {
-		numberFile, err := os.Create(filepath.Join(destDir, fileName))
+	d, err := driver.FromSource(req.Source)
+	if err != nil {
+		return i.fatal("construction driver", err)
+	}
+	scheme, err := req.Source.VersionScheme()
+	if err != nil {
+		return i.fatal("construction driver", err)
+	}
+	if r, ok := d.(driver.BuildRecorder); ok {
+		r.SetBuild(resource.BuildMetadataFromEnv(i.getenv))
+	}
+	if req.Params.Branch != "" {
+		if err := driver.SelectBranch(d, req.Params.Branch); err != nil {
+			return i.fatal("selecting branch", err)
+		}
+	}
+	// check does not know the branch, so the version of the branch is read from its file
+	if req.Params.Branch != "" && !req.Params.Reserve {
+		g, ok := d.(driver.Getter)
+		if !ok {
+			return i.fatal("reading version of branch", fmt.Errorf("%s driver does not support reading the version", req.Source.Driver))
+		}
+		current, err := g.Get(ctx)
 		if err != nil {
-			return i.fatal("opening number file", err)
+			return i.fatal("reading version of branch", err)
+		}
+		if current != req.Version.Number {
+			fmt.Fprintf(i.ErrStream, "warning: version %s of branch %s is not the requested version %s, and the later builds may get it from the cache of Concourse; see branch param in README\n", current, req.Params.Branch, req.Version.Number)
 		}
-		defer numberFile.Close()
+		req.Version = resource.Version{Number: current}
+	}
 
-		if _, err := fmt.Fprintf(numberFile, "%s", version); err != nil {
-			return i.fatal("writing number file", err)
+	// the requested version may be pinned, so it must be stored in the past
+	var stored *driver.Record
+	if !req.Params.Reserve {
+		if stored, err = driver.FindVersion(ctx, d, req.Version.Number, req.Source.InitialVersionOrDefault()); err != nil {
+			return i.fatal("finding version", err)
 		}
 	}
 
 	res := resource.InResponse{
 		Version: req.Version,
-		Metadata: []resource.MetadataField{
+	}
+	version := req.Version.Number
+	timestamp := time.Now().UTC().Truncate(time.Second)
+	switch {
+	case req.Params.Reserve:
+		// the version is bumped atomically, so that it is not taken by the other builds
+		fmt.Fprintln(i.ErrStream, "warning: reserve may be skipped by the cache of Concourse; see reserve param in README")
+		reserved, err := d.Bump(ctx)
+		if err != nil {
+			return i.fatal("reserving version", err)
+		}
+		var metadata resource.Metadata
+		if r, ok := d.(driver.MetadataReporter); ok {
+			metadata = r.Metadata()
+		}
+		version = reserved
+		res.Version = resource.Version{Number: reserved}
+		res.Metadata = []resource.MetadataField{
+			{Name: "number", Value: reserved},
+			{Name: "bumped_from", Value: metadata.Value("previous")},
+			{Name: "bumped_to", Value: reserved},
+		}
+		for _, f := range metadata {
+			if f.Name != "previous" {
+				res.Metadata = append(res.Metadata, f)
+			}
+		}
+	case req.Params.Bump:
+		// the bumped version is a local preview, which is not stored in the driver
+		if version, err = resource.NextVersion(scheme, version, 1); err != nil {
+			return i.fatal("bumping version", err)
+		}
+		res.Metadata = []resource.MetadataField{
+			{Name: "number", Value: req.Version.Number},
+			{Name: "bumped_from", Value: req.Version.Number},
+			{Name: "bumped_to", Value: version},
+			{Name: "reserved", Value: "false"},
+		}
+	default:
+		res.Metadata = []resource.MetadataField{
 			{Name: "number", Value: req.Version.Number},
-		},
+		}
+	}
+	if req.Params.Branch != "" && !req.Params.Reserve {
+		res.Metadata = append(res.Metadata, resource.MetadataField{Name: "branch", Value: req.Params.Branch})
+	}
+	if stored != nil {
+		res.Metadata = append(res.Metadata, storedMetadata(*stored)...)
+	}
+
+	// the commit is known if the files have the version stored in the driver
+	var commit string
+	switch {
+	case req.Params.Reserve:
+		commit = res.Metadata.Value("commit")
+	case stored != nil && !req.Params.Bump:
+		commit, timestamp = stored.Commit, stored.Time.UTC()
+	}
+
+	output := resource.VersionOutput{
+		Number:    version,
+		Version:   version,
+		Commit:    commit,
+		Timestamp: timestamp,
+		Branch:    req.Params.Branch,
+	}
+	if err := writeOutputs(destDir, output, req.Params.OutputTemplates); err != nil {
+		return i.fatal("writing outputs", err)
 	}
 
 	if err := json.NewEncoder(i.OutStream).Encode(res); err != nil {
@@ -72,8 +170,59 @@ func (i *In) Execute(args []string) int {
 	return 0
 }
 
+// storedMetadata returns the metadata of the record which stored the version
+func storedMetadata(r driver.Record) []resource.MetadataField {
+	var metadata []resource.MetadataField
+	for _, f := range []resource.MetadataField{
+		{Name: "commit", Value: r.Commit},
+		{Name: "author", Value: r.Author},
+		{Name: "time", Value: r.Time.UTC().Format(time.RFC3339)},
+		{Name: "build_url", Value: r.BuildURL},
+	} {
+		if f.Value != "" {
+			metadata = append(metadata, f)
+		}
+	}
+	return metadata
+}
+
+// writeOutputs writes the version to the files in the destination
+func writeOutputs(destDir string, output resource.VersionOutput, templates map[string]string) error {
+	files := map[string]string{
+		"number":      output.Version,
+		"version":     output.Version,
+		"version.env": output.Env(),
+	}
+	versionJSON, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	files["version.json"] = string(versionJSON) + "\n"
+	for name, text := range templates {
+		content, err := output.Render(name, text)
+		if err != nil {
+			return err
+		}
+		files[name] = content
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(destDir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *In) getenv(key string) string {
+	if i.Getenv == nil {
+		return ""
+	}
+	return i.Getenv(key)
+}
+
 func (i *In) fatal(doing string, err error) int {
-	fmt.Fprintf(i.ErrStream, "error %s: %v", doing, err)
+	driver.ReportError(i.ErrStream, i.redactor, i.errorFormat, doing, err)
 	return 1
 }
 
@@ -82,6 +231,11 @@ func main() {
 		InStream:  os.Stdin,
 		ErrStream: os.Stderr,
 		OutStream: os.Stdout,
+		Getenv:    os.Getenv,
 	}
-	os.Exit(c.Execute(os.Args))
+	// Concourse sends SIGTERM when the build is aborted
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	code := c.Execute(ctx, os.Args)
+	stop()
+	os.Exit(code)
 }
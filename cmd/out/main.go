@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	resource "github.com/cappyzawa/romver-resource"
 	"github.com/cappyzawa/romver-resource/driver"
@@ -16,10 +19,14 @@ type Out struct {
 	InStream  io.Reader
 	ErrStream io.Writer
 	OutStream io.Writer
+	Getenv    func(string) string
+
+	errorFormat string
+	redactor    *resource.Redactor
 }
 
 // Execute executes out command
-func (o *Out) Execute(args []string) int {
+func (o *Out) Execute(ctx context.Context, args []string) int {
 	if len(args) < 2 {
 		fmt.Fprintf(o.ErrStream, "usage: %s <source>", args[0])
 		return 1
@@ -31,36 +38,45 @@ func (o *Out) Execute(args []string) int {
 	if err := json.NewDecoder(o.InStream).Decode(&req); err != nil {
 		return o.fatal("decoding request", err)
 	}
+	o.errorFormat = req.Source.ErrorFormat
+	o.redactor = resource.NewRedactor(driver.Secrets(req.Source)...)
+
+	if err := driver.Validate(req.Source, req.Params); err != nil {
+		return o.fatal("validating request", err)
+	}
 
-	driver, err := driver.FromSource(req.Source)
+	d, err := driver.FromSource(req.Source)
 	if err != nil {
 		return o.fatal("construction driver", err)
 	}
+	build := resource.BuildMetadataFromEnv(o.getenv)
+	if r, ok := d.(driver.BuildRecorder); ok {
+		r.SetBuild(build)
+	}
+	if req.Params.Branch != "" {
+		if err := driver.SelectBranch(d, req.Params.Branch); err != nil {
+			return o.fatal("selecting branch", err)
+		}
+	}
+
+	if len(req.Params.Counters) > 0 {
+		return o.updateCounters(ctx, d, sourceDir, req, build)
+	}
 
 	var newVersion string
 	if req.Params.File != "" {
-		versionFile, err := os.Open(filepath.Join(sourceDir, req.Params.File))
+		newVersion, err = readVersionFile(sourceDir, req.Params.File, req.Source)
 		if err != nil {
-			return o.fatal("opening version file", err)
+			return o.fatal("reading version file", err)
 		}
-		defer versionFile.Close()
-
-		var versionStr string
-		if _, err := fmt.Fscanf(versionFile, "%s", &versionStr); err != nil {
-			return o.fatal("reading versin file", err)
-		}
-		newVersion = versionStr
-		if err := driver.Set(newVersion); err != nil {
+		if err := d.Set(ctx, newVersion); err != nil {
 			return o.fatal("setting version", err)
 		}
-	} else if req.Params.Bump {
-		newVersion, err = driver.Bump()
+	} else {
+		newVersion, err = d.Bump(ctx)
 		if err != nil {
 			return o.fatal("dumping version", err)
 		}
-	} else {
-		fmt.Fprint(o.ErrStream, "no version dump specified")
-		return 1
 	}
 
 	res := resource.OutResponse{
@@ -71,6 +87,57 @@ func (o *Out) Execute(args []string) int {
 			{Name: "number", Value: newVersion},
 		},
 	}
+	return o.respond(withDriverMetadata(res, d, build))
+}
+
+// updateCounters updates the counters in a single operation.
+// The counter of the source is the version of the put, and all the counters are reported in metadata.
+func (o *Out) updateCounters(ctx context.Context, d driver.Driver, sourceDir string, req resource.OutRequest, build resource.BuildMetadata) int {
+	updater, ok := d.(driver.Updater)
+	if !ok {
+		return o.fatal("updating counters", fmt.Errorf("%s driver does not support counters", req.Source.Driver))
+	}
+
+	updates := make([]driver.Update, len(req.Params.Counters))
+	for i, c := range req.Params.Counters {
+		updates[i] = driver.Update{File: c.Path, Counter: c.Counter, Bump: c.Bump}
+		if c.File != "" {
+			version, err := readVersionFile(sourceDir, c.File, req.Source)
+			if err != nil {
+				return o.fatal("reading version file", err)
+			}
+			updates[i].Version = version
+		}
+	}
+
+	versions, err := updater.Update(ctx, updates)
+	if err != nil {
+		return o.fatal("updating counters", err)
+	}
+
+	var res resource.OutResponse
+	for _, v := range versions {
+		if v.Own {
+			res.Version = resource.Version{Number: v.Version}
+			res.Metadata = append(resource.Metadata{{Name: "number", Value: v.Version}}, res.Metadata...)
+		}
+		res.Metadata = append(res.Metadata, resource.MetadataField{Name: v.Label, Value: v.Version})
+	}
+	return o.respond(withDriverMetadata(res, d, build))
+}
+
+// withDriverMetadata appends the metadata of the driver and the link to the build to the response
+func withDriverMetadata(res resource.OutResponse, d driver.Driver, build resource.BuildMetadata) resource.OutResponse {
+	if r, ok := d.(driver.MetadataReporter); ok {
+		res.Metadata = append(res.Metadata, r.Metadata()...)
+	}
+	if u := build.URL(); u != "" {
+		res.Metadata = append(res.Metadata, resource.MetadataField{Name: "build_url", Value: u})
+	}
+	return res
+}
+
+func (o *Out) respond(res resource.OutResponse) int {
 	if err := json.NewEncoder(o.OutStream).Encode(res); err != nil {
 		return o.fatal("encoding response", err)
 	}
@@ -78,8 +145,39 @@ func (o *Out) Execute(args []string) int {
 	return 0
 }
 
+// readVersionFile returns the version in the file of the build,
+// and invalid_version error if it is not valid in the scheme of the source
+func readVersionFile(sourceDir, path string, source resource.Source) (string, error) {
+	versionFile, err := os.Open(filepath.Join(sourceDir, path))
+	if err != nil {
+		return "", err
+	}
+	defer versionFile.Close()
+
+	var version string
+	if _, err := fmt.Fscanf(versionFile, "%s", &version); err != nil {
+		return "", err
+	}
+
+	scheme, err := source.VersionScheme()
+	if err != nil {
+		return "", &driver.Error{Kind: driver.KindInvalidConfig, Op: "reading scheme", Err: err}
+	}
+	if err := resource.ValidateVersion(scheme, version); err != nil {
+		return "", &driver.Error{Kind: driver.KindInvalidVersion, Op: "parsing " + path, Err: err}
+	}
+	return version, nil
+}
+
+func (o *Out) getenv(key string) string {
+	if o.Getenv == nil {
+		return ""
+	}
+	return o.Getenv(key)
+}
+
 func (o *Out) fatal(doing string, err error) int {
-	fmt.Fprintf(o.ErrStream, "error %s: %v", doing, err)
+	driver.ReportError(o.ErrStream, o.redactor, o.errorFormat, doing, err)
 	return 1
 }
 
@@ -88,6 +186,11 @@ func main() {
 		InStream:  os.Stdin,
 		ErrStream: os.Stderr,
 		OutStream: os.Stdout,
+		Getenv:    os.Getenv,
 	}
-	os.Exit(c.Execute(os.Args))
+	// Concourse sends SIGTERM when the build is aborted
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	code := c.Execute(ctx, os.Args)
+	stop()
+	os.Exit(code)
 }
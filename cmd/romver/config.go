@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+)
+
+// envPrefix is the prefix of environment variables for source keys, e.g. ROMVER_URI
+const envPrefix = "ROMVER_"
+
+// sourceKey is the key of source which can be configured by flags and environment variables
+type sourceKey struct {
+	name string
+	kind reflect.Kind
+}
+
+// flagName returns the name of the flag for the key, e.g. --private-key for private_key
+func (k sourceKey) flagName() string {
+	return strings.Replace(k.name, "_", "-", -1)
+}
+
+// envName returns the name of the environment variable for the key, e.g. ROMVER_PRIVATE_KEY
+func (k sourceKey) envName() string {
+	return envPrefix + strings.ToUpper(k.name)
+}
+
+// parse converts the value to the type of the key
+func (k sourceKey) parse(value string) (interface{}, error) {
+	switch k.kind {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.Atoi(value)
+	case reflect.Slice:
+		// e.g. commit_trailers, whose items may contain commas
+		return strings.Split(value, "\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// sourceKeys returns the common keys and the keys of all registered drivers
+func sourceKeys() []sourceKey {
+	kinds := map[string]reflect.Kind{}
+	collect := func(v interface{}, only map[string]bool) {
+		t := reflect.Indirect(reflect.ValueOf(v)).Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.PkgPath != "" || name == "" || name == "-" {
+				continue
+			}
+			if only != nil && !only[name] {
+				continue
+			}
+			kinds[name] = f.Type.Kind()
+		}
+	}
+
+	common := map[string]bool{}
+	for _, k := range resource.CommonSourceKeys {
+		common[k] = true
+	}
+	collect(resource.Source{}, common)
+	for _, name := range driver.Drivers() {
+		if config, err := driver.NewConfig(name); err == nil {
+			collect(config, nil)
+		}
+	}
+
+	var keys []sourceKey
+	for name, kind := range kinds {
+		keys = append(keys, sourceKey{name: name, kind: kind})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].name < keys[j].name
+	})
+	return keys
+}
+
+// sourceFlags defines the flags for source keys on fs
+type sourceFlags struct {
+	config string
+	values map[string]*string
+}
+
+func newSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	sf := &sourceFlags{values: map[string]*string{}}
+	fs.StringVar(&sf.config, "config", "", "path to JSON or YAML file of the source configuration")
+	for _, k := range sourceKeys() {
+		v := &flagValue{isBool: k.kind == reflect.Bool}
+		fs.Var(v, k.flagName(), fmt.Sprintf("source %s (env: %s)", k.name, k.envName()))
+		sf.values[k.name] = &v.value
+	}
+	return sf
+}
+
+// flagValue is the value of the flag for source key.
+// The flag for boolean key can be specified without value, e.g. --create-branch
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// source returns the source configured by the file, environment variables and flags,
+// the latter takes precedence.
+func (sf *sourceFlags) source(fs *flag.FlagSet, getenv func(string) string) (resource.Source, error) {
+	values := map[string]interface{}{}
+	if sf.config != "" {
+		var err error
+		if values, err = readConfig(sf.config); err != nil {
+			return resource.Source{}, err
+		}
+	}
+
+	keys := map[string]sourceKey{}
+	for _, k := range sourceKeys() {
+		keys[k.name] = k
+		if env := getenv(k.envName()); env != "" {
+			v, err := k.parse(env)
+			if err != nil {
+				return resource.Source{}, fmt.Errorf("invalid %s: %v", k.envName(), err)
+			}
+			values[k.name] = v
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		k, ok := keys[strings.Replace(f.Name, "-", "_", -1)]
+		if !ok {
+			return
+		}
+		v, err := k.parse(f.Value.String())
+		if err != nil && flagErr == nil {
+			flagErr = fmt.Errorf("invalid --%s: %v", f.Name, err)
+		}
+		values[k.name] = v
+	})
+	if flagErr != nil {
+		return resource.Source{}, flagErr
+	}
+
+	return resource.NewSource(values)
+}
+
+// readConfig reads the source configuration.
+// The file may be the resource definition of Concourse which has the source under "source".
+func readConfig(path string) (map[string]interface{}, error) {
+	values, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if source, ok := values["source"].(map[string]interface{}); ok {
+		return source, nil
+	}
+	return values, nil
+}
+
+// readFile reads the JSON or YAML object in the file
+func readFile(path string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(b, &values)
+	default:
+		err = yaml.Unmarshal(b, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+)
+
+var _ = Describe("sourceFlags", func() {
+	var (
+		dir  string
+		env  map[string]string
+		args []string
+	)
+
+	// load parses the args and returns the source configured by the file, env and the flags
+	load := func() (resource.Source, error) {
+		fs := flag.NewFlagSet("romver get", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		sf := newSourceFlags(fs)
+		Expect(fs.Parse(args)).To(Succeed())
+		return sf.source(fs, func(key string) string {
+			return env[key]
+		})
+	}
+	// gitConfig returns the configuration of git driver in the source
+	gitConfig := func(source resource.Source) driver.GitConfig {
+		var c driver.GitConfig
+		Expect(driver.DecodeConfig(source, &c)).To(Succeed())
+		return c
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "romver-config")
+		Expect(err).NotTo(HaveOccurred())
+		env = map[string]string{}
+		args = nil
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Context("when the key is configured in several ways", func() {
+		BeforeEach(func() {
+			config := filepath.Join(dir, "pipeline.yml")
+			content := "source:\n  driver: git\n  uri: https://example.com/file.git\n  branch: file\n  file: file\n"
+			Expect(ioutil.WriteFile(config, []byte(content), 0644)).To(Succeed())
+			env["ROMVER_BRANCH"] = "env"
+			env["ROMVER_FILE"] = "env"
+			args = []string{"--config", config, "--file", "flag"}
+		})
+		It("takes the file, env and flags in order of precedence", func() {
+			source, err := load()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source.Driver).To(Equal(resource.DriverGit))
+			c := gitConfig(source)
+			Expect(c.URI).To(Equal("https://example.com/file.git"))
+			Expect(c.Branch).To(Equal("env"))
+			Expect(c.File).To(Equal("flag"))
+		})
+	})
+
+	table.DescribeTable("parsing the flags",
+		func(flags []string, expected func(driver.GitConfig)) {
+			args = append([]string{"--driver", "git"}, flags...)
+			source, err := load()
+			Expect(err).NotTo(HaveOccurred())
+			expected(gitConfig(source))
+		},
+		table.Entry("bool flag without value", []string{"--create-branch"}, func(c driver.GitConfig) {
+			Expect(c.CreateBranch).To(BeTrue())
+		}),
+		table.Entry("bool flag with false", []string{"--create-branch=false"}, func(c driver.GitConfig) {
+			Expect(c.CreateBranch).To(BeFalse())
+		}),
+		table.Entry("bool flag followed by the other flag", []string{"--skip-ssl-verification", "--file", "version"}, func(c driver.GitConfig) {
+			Expect(c.SkipSSLVerification).To(BeTrue())
+			Expect(c.File).To(Equal("version"))
+		}),
+		table.Entry("int flag", []string{"--max-retries", "3"}, func(c driver.GitConfig) {
+			Expect(c.MaxRetries).To(Equal(3))
+		}),
+		table.Entry("slice flag split on newlines", []string{"--commit-trailers", "Reviewed-by: a, b\nRefs: #1"}, func(c driver.GitConfig) {
+			Expect(c.CommitTrailers).To(Equal([]string{"Reviewed-by: a, b", "Refs: #1"}))
+		}),
+	)
+
+	table.DescribeTable("parsing the environment variables",
+		func(key, value string, expected func(driver.GitConfig)) {
+			env["ROMVER_DRIVER"] = "git"
+			env[key] = value
+			source, err := load()
+			Expect(err).NotTo(HaveOccurred())
+			expected(gitConfig(source))
+		},
+		table.Entry("bool", "ROMVER_CREATE_BRANCH", "true", func(c driver.GitConfig) {
+			Expect(c.CreateBranch).To(BeTrue())
+		}),
+		table.Entry("slice split on newlines", "ROMVER_COMMIT_TRAILERS", "Refs: #1\nRefs: #2", func(c driver.GitConfig) {
+			Expect(c.CommitTrailers).To(Equal([]string{"Refs: #1", "Refs: #2"}))
+		}),
+	)
+
+	table.DescribeTable("invalid values",
+		func(setUp func(), message string) {
+			setUp()
+			_, err := load()
+			Expect(err).To(MatchError(message))
+		},
+		table.Entry("int flag", func() {
+			args = []string{"--max-retries", "many"}
+		}, `invalid --max-retries: strconv.Atoi: parsing "many": invalid syntax`),
+		table.Entry("bool env", func() {
+			env["ROMVER_CREATE_BRANCH"] = "yes"
+		}, `invalid ROMVER_CREATE_BRANCH: strconv.ParseBool: parsing "yes": invalid syntax`),
+	)
+})
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+	"github.com/cappyzawa/romver-resource/romver"
+)
+
+const usage = `usage: romver <command> [flags]
+
+Commands:
+  get       print the current version
+  bump      increment the version and print it
+  set       store the given version: romver set [flags] <version>
+  history   print the past versions: version, commit, author and time
+  init      store initial_version if no version is stored yet
+  serve     serve the counters over HTTP: romver serve --config <file>
+
+The source is configured by --config file, ROMVER_* environment variables and flags,
+the latter takes precedence. Run "romver <command> -h" to list the flags.
+`
+
+// CLI represents romver command stream
+type CLI struct {
+	OutStream io.Writer
+	ErrStream io.Writer
+	Getenv    func(string) string
+
+	errorFormat string
+	redactor    *resource.Redactor
+}
+
+// Execute executes the subcommand
+func (c *CLI) Execute(ctx context.Context, args []string) int {
+	if len(args) < 2 {
+		fmt.Fprint(c.ErrStream, usage)
+		return 2
+	}
+
+	command := args[1]
+	switch command {
+	case "get", "bump", "set", "history", "init":
+	case "serve":
+		return c.serve(ctx, args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(c.OutStream, usage)
+		return 0
+	default:
+		fmt.Fprintf(c.ErrStream, "unknown command: %s\n\n%s", command, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("romver "+command, flag.ContinueOnError)
+	fs.SetOutput(c.ErrStream)
+	sf := newSourceFlags(fs)
+	limit := 0
+	if command == "history" {
+		fs.IntVar(&limit, "n", 0, "the number of versions to print (0 means all)")
+	}
+	if err := fs.Parse(args[2:]); err != nil {
+		return 2
+	}
+
+	source, err := sf.source(fs, c.Getenv)
+	if err != nil {
+		return c.fatal("loading source", err)
+	}
+	c.errorFormat = source.ErrorFormat
+	c.redactor = resource.NewRedactor(driver.Secrets(source)...)
+
+	// the credentials and the git config must not be written to HOME of the workstation
+	workDir, err := ioutil.TempDir("", "romver")
+	if err != nil {
+		return c.fatal("creating work directory", err)
+	}
+	defer os.RemoveAll(workDir)
+	if gitUser(source) == "" {
+		inheritGitIdentity()
+	}
+
+	counter, err := romver.FromSourceInDir(source, workDir)
+	if err != nil {
+		return c.fatal("opening counter", err)
+	}
+
+	switch command {
+	case "get":
+		version, err := counter.Get(ctx)
+		if err != nil {
+			return c.fatal("getting version", err)
+		}
+		fmt.Fprintln(c.OutStream, version)
+	case "bump":
+		version, err := counter.Bump(ctx, 1)
+		if err != nil {
+			return c.fatal("bumping version", err)
+		}
+		fmt.Fprintln(c.OutStream, version)
+	case "set":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(c.ErrStream, "usage: romver set [flags] <version>")
+			return 2
+		}
+		version := fs.Arg(0)
+		if err := counter.Set(ctx, version); err != nil {
+			return c.fatal("setting version", err)
+		}
+		fmt.Fprintln(c.OutStream, version)
+	case "history":
+		records, err := counter.History(ctx, limit)
+		if err != nil {
+			return c.fatal("listing history", err)
+		}
+		for _, r := range records {
+			fmt.Fprintf(c.OutStream, "%s\t%s\t%s\t%s\n", r.Version, r.Commit, r.Author, r.Time.Format(time.RFC3339))
+		}
+	case "init":
+		created, err := counter.Init(ctx)
+		if err != nil {
+			return c.fatal("initializing version", err)
+		}
+		if !created {
+			fmt.Fprintln(c.ErrStream, "version already exists")
+		}
+	}
+	return 0
+}
+
+// gitUser returns git_user of the source, or empty if the driver is not git
+func gitUser(source resource.Source) string {
+	var c driver.GitConfig
+	if source.Driver != resource.DriverGit || driver.DecodeConfig(source, &c) != nil {
+		return ""
+	}
+	return c.GitUser
+}
+
+// inheritGitIdentity makes the commits authored by the identity in git config of the workstation,
+// since it is not available from the work directory
+func inheritGitIdentity() {
+	for key, envs := range map[string][]string{
+		"user.name":  {"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"},
+		"user.email": {"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"},
+	} {
+		out, err := exec.Command("git", "config", key).Output()
+		if err != nil {
+			continue
+		}
+		for _, env := range envs {
+			if os.Getenv(env) == "" {
+				os.Setenv(env, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+}
+
+func (c *CLI) fatal(doing string, err error) int {
+	driver.ReportError(c.ErrStream, c.redactor, c.errorFormat, doing, err)
+	return 1
+}
+
+func main() {
+	c := &CLI{
+		OutStream: os.Stdout,
+		ErrStream: os.Stderr,
+		Getenv:    os.Getenv,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	code := c.Execute(ctx, os.Args)
+	stop()
+	os.Exit(code)
+}
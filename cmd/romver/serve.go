@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+	"github.com/cappyzawa/romver-resource/server"
+)
+
+const serveUsage = `usage: romver serve --config <file> [--addr :8080] [--token <token>]
+
+The file has the source of each counter under "counters":
+
+  token: secret
+  counters:
+    web:
+      driver: git
+      uri: git@github.com:cappyzawa/romver.git
+      branch: version
+      file: web
+`
+
+// serve runs the HTTP server until ctx is done
+func (c *CLI) serve(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("romver serve", flag.ContinueOnError)
+	fs.SetOutput(c.ErrStream)
+	fs.Usage = func() {
+		fmt.Fprint(c.ErrStream, serveUsage)
+		fs.PrintDefaults()
+	}
+	config := fs.String("config", "", "path to JSON or YAML file of the counters")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	token := fs.String("token", "", "bearer token required for the requests (env: "+envPrefix+"SERVE_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *config == "" {
+		fs.Usage()
+		return 2
+	}
+
+	sources, fileToken, err := readServeConfig(*config)
+	if err != nil {
+		return c.fatal("loading counters", err)
+	}
+	srv := &server.Server{Token: *token}
+	if srv.Token == "" {
+		srv.Token = c.Getenv(envPrefix + "SERVE_TOKEN")
+	}
+	if srv.Token == "" {
+		srv.Token = fileToken
+	}
+
+	var secrets []string
+	inherit := true
+	for _, source := range sources {
+		secrets = append(secrets, driver.Secrets(source)...)
+		if gitUser(source) != "" {
+			inherit = false
+		}
+	}
+	secrets = append(secrets, srv.Token)
+	c.redactor = resource.NewRedactor(secrets...)
+	srv.Redactor = c.redactor
+
+	workDir, err := ioutil.TempDir("", "romver")
+	if err != nil {
+		return c.fatal("creating work directory", err)
+	}
+	defer os.RemoveAll(workDir)
+	// the identity in the environment would override git_user of the counters
+	if inherit {
+		inheritGitIdentity()
+	}
+
+	if srv.Counters, err = server.OpenCounters(sources, workDir); err != nil {
+		return c.fatal("loading counters", err)
+	}
+
+	hs := &http.Server{Addr: *addr, Handler: srv}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- hs.ListenAndServe()
+	}()
+	fmt.Fprintf(c.ErrStream, "serving %d counter(s) on %s\n", len(srv.Counters), *addr)
+
+	select {
+	case err := <-errCh:
+		return c.fatal("serving", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := hs.Shutdown(shutdownCtx); err != nil {
+		return c.fatal("shutting down", err)
+	}
+	return 0
+}
+
+// readServeConfig reads the sources of the counters and the token from the file
+func readServeConfig(path string) (map[string]resource.Source, string, error) {
+	values, err := readFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	counters, ok := values["counters"].(map[string]interface{})
+	if !ok || len(counters) == 0 {
+		return nil, "", errors.New("no counters are defined under counters")
+	}
+	token, _ := values["token"].(string)
+
+	sources := map[string]resource.Source{}
+	for name, v := range counters {
+		values, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, "", fmt.Errorf("counter %s: the source must be an object", name)
+		}
+		source, err := resource.NewSource(values)
+		if err != nil {
+			return nil, "", fmt.Errorf("counter %s: %v", name, err)
+		}
+		sources[name] = source
+	}
+	return sources, token, nil
+}
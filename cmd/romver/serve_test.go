@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cappyzawa/romver-resource/driver"
+)
+
+var _ = Describe("readServeConfig", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "romver-serve")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("reads the nested sources of the counters in YAML", func() {
+		config := filepath.Join(dir, "counters.yml")
+		content := "token: secret\ncounters:\n  web:\n    driver: git\n    uri: https://example.com/repo.git\n    branch: version\n    file: web\n    commit_trailers:\n    - \"Refs: #1\"\n"
+		Expect(ioutil.WriteFile(config, []byte(content), 0644)).To(Succeed())
+
+		sources, token, err := readServeConfig(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("secret"))
+		Expect(sources).To(HaveKey("web"))
+		var c driver.GitConfig
+		Expect(driver.DecodeConfig(sources["web"], &c)).To(Succeed())
+		Expect(c.File).To(Equal("web"))
+		Expect(c.CommitTrailers).To(Equal([]string{"Refs: #1"}))
+	})
+})
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cappyzawa/romver-resource/schema"
+)
+
+// Schema represents schema command stream
+type Schema struct {
+	ErrStream io.Writer
+	OutStream io.Writer
+}
+
+// Execute prints JSON Schema of source and params
+func (s *Schema) Execute(args []string) int {
+	sc, err := schema.Generate()
+	if err != nil {
+		return s.fatal("generating schema", err)
+	}
+
+	enc := json.NewEncoder(s.OutStream)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sc); err != nil {
+		return s.fatal("encoding schema", err)
+	}
+	return 0
+}
+
+func (s *Schema) fatal(doing string, err error) int {
+	fmt.Fprintf(s.ErrStream, "error %s: %v\n", doing, err)
+	return 1
+}
+
+func main() {
+	c := &Schema{
+		ErrStream: os.Stderr,
+		OutStream: os.Stdout,
+	}
+	os.Exit(c.Execute(os.Args))
+}
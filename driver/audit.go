@@ -0,0 +1,137 @@
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AuditEntry is the line of audit_log, which records a change of the version
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Operation is "bump", "set" or "init"
+	Operation string `json:"operation"`
+	File      string `json:"file"`
+	Counter   string `json:"counter,omitempty"`
+	// Previous is the version before the change, empty if it was not stored
+	Previous string `json:"previous,omitempty"`
+	Version  string `json:"version"`
+	// Actor is the git identity which made the commit
+	Actor    string `json:"actor,omitempty"`
+	BuildURL string `json:"build_url,omitempty"`
+}
+
+// writeAuditLog appends the entries to audit_log in the clone.
+// It is committed with the version files so that both are updated atomically.
+func (gd *GitDriver) writeAuditLog(ctx context.Context, entries []AuditEntry) error {
+	if gd.AuditLog == "" || len(entries) == 0 {
+		return nil
+	}
+
+	actor := gd.actor(ctx)
+	now := time.Now().UTC().Truncate(time.Second)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// the actor is "name <email>"
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		e.Time = now
+		e.Actor = actor
+		e.BuildURL = gd.build.URL()
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	path := filepath.Join(gd.files().repoDir, gd.AuditLog)
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// the last line may not be terminated if the file is edited by hand
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		content = append(content, '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, buf.Bytes()...), 0644)
+}
+
+// actor returns the identity of the committer as "name <email>"
+func (gd *GitDriver) actor(ctx context.Context) string {
+	gitVar := gd.gitCommand("var", "GIT_COMMITTER_IDENT")
+	gitVar.Dir = gd.files().repoDir
+	output, err := gd.Runner.CombinedOutput(ctx, gitVar)
+	if err != nil {
+		return ""
+	}
+	// the ident is followed by the timestamp, e.g. "name <email> 1700000000 +0000"
+	ident := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(ident, ">"); i >= 0 {
+		return ident[:i+1]
+	}
+	return ident
+}
+
+// readAuditLog returns the entries of the file and the counter in audit_log from newest to oldest
+func (gd *GitDriver) readAuditLog(limit int) ([]Record, error) {
+	f, err := os.Open(filepath.Join(gd.files().repoDir, gd.AuditLog))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, fmt.Errorf("parsing %s: line %d: %v", gd.AuditLog, n, err)
+		}
+		if e.File != gd.File || e.Counter != gd.Counter {
+			continue
+		}
+		records = append(records, Record{
+			Version:   e.Version,
+			Previous:  e.Previous,
+			Operation: e.Operation,
+			Author:    e.Actor,
+			BuildURL:  e.BuildURL,
+			Time:      e.Time,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// withAuditLog appends audit_log to the files to commit if it is configured
+func (gd *GitDriver) withAuditLog(files ...string) []string {
+	if gd.AuditLog == "" {
+		return files
+	}
+	return append(files, gd.AuditLog)
+}
@@ -0,0 +1,171 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+// counterNameRegexp matches the name of the counter which can be a key in both formats
+var counterNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// counterFile is the file which holds the named counters.
+// The file with .yml or .yaml extension is a YAML map, otherwise it consists of "name=number" lines.
+// The other counters, the order and the comments of the lines are preserved on update.
+type counterFile struct {
+	yaml  bool
+	lines []string
+	// doc is the YAML document, which keeps the comments and the text of the values
+	doc *yaml.Node
+}
+
+func isYAMLFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		return true
+	}
+	return false
+}
+
+// parseCounterFile parses the content of the file at path
+func parseCounterFile(path string, content []byte) (*counterFile, error) {
+	f := &counterFile{yaml: isYAMLFile(path)}
+	if f.yaml {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("parsing %s: %v", path, err)
+		}
+		if doc.Kind == 0 {
+			// the file is empty
+			return f, nil
+		}
+		if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("parsing %s: must be a map of the counters", path)
+		}
+		f.doc = &doc
+		return f, nil
+	}
+
+	text := strings.TrimRight(string(content), "\n")
+	if text == "" {
+		return f, nil
+	}
+	f.lines = strings.Split(text, "\n")
+	for i, line := range f.lines {
+		if _, _, ok := parseCounterLine(line); !ok && !isBlankOrComment(line) {
+			return nil, fmt.Errorf("parsing %s: line %d must be name=number: %q", path, i+1, line)
+		}
+	}
+	return f, nil
+}
+
+// get returns the version of the counter
+func (f *counterFile) get(name string) (string, bool) {
+	if f.yaml {
+		if v := f.yamlValue(name); v != nil {
+			return v.Value, true
+		}
+		return "", false
+	}
+
+	for _, line := range f.lines {
+		if n, v, ok := parseCounterLine(line); ok && n == name {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// set updates the version of the counter, or appends the counter
+func (f *counterFile) set(name, version string) {
+	if f.yaml {
+		// the number is not quoted in YAML unless it overflows the integer of YAML parsers,
+		// and the others such as calver are strings, e.g. "2026.10" is not a float
+		tag := "!!str"
+		if v, err := (resource.IntegerScheme{}).Parse(version); err == nil && v.(*big.Int).IsInt64() {
+			tag = "!!int"
+		}
+		if v := f.yamlValue(name); v != nil {
+			v.Kind, v.Tag, v.Value, v.Style = yaml.ScalarNode, tag, version, 0
+			v.Content = nil
+			return
+		}
+		if f.doc == nil {
+			f.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		}
+		m := f.doc.Content[0]
+		m.Content = append(m.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: version},
+		)
+		return
+	}
+
+	for i, line := range f.lines {
+		if n, _, ok := parseCounterLine(line); ok && n == name {
+			f.lines[i] = name + "=" + version
+			return
+		}
+	}
+	f.lines = append(f.lines, name+"="+version)
+}
+
+// bytes returns the content of the file
+func (f *counterFile) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if f.yaml {
+		if f.doc == nil {
+			return nil, nil
+		}
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(f.doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	for _, line := range f.lines {
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlValue returns the node of the value of the counter, or nil if it does not exist
+func (f *counterFile) yamlValue(name string) *yaml.Node {
+	if f.doc == nil {
+		return nil
+	}
+	m := f.doc.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == name {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func parseCounterLine(line string) (string, string, bool) {
+	if isBlankOrComment(line) {
+		return "", "", false
+	}
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), true
+}
+
+func isBlankOrComment(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
@@ -1,48 +1,190 @@
 package driver
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	resource "github.com/cappyzawa/romver-resource"
 )
 
 // Driver operates the versioning
 type Driver interface {
-	Bump() (string, error)
-	Set(string) error
-	Check(string) ([]string, error)
+	Bump(context.Context) (string, error)
+	Set(context.Context, string) error
+	Check(context.Context, string) ([]string, error)
+}
+
+// Stepper is implemented by the driver which can increment the version by more than one atomically
+type Stepper interface {
+	BumpBy(ctx context.Context, n int) (string, error)
+}
+
+// Updater is implemented by the driver which can update several versions atomically.
+// One of the updates must be the version of the source.
+// It returns the stored versions in the order of the updates.
+type Updater interface {
+	Update(ctx context.Context, updates []Update) ([]Updated, error)
+}
+
+// Updated represents the version stored by Updater
+type Updated struct {
+	// Label is the location of the version, e.g. the file and the counter in it
+	Label   string
+	Version string
+	// Own reports whether the version is the one of the source
+	Own bool
+}
+
+// Update represents the change of the version in Updater.
+// File and Counter locate the version, and the ones of the source are used if they are empty.
+type Update struct {
+	File    string
+	Counter string
+	// Bump increments the current version, otherwise Version is stored
+	Bump    bool
+	Version string
+}
+
+// BranchSelector is implemented by the driver which keeps an independent version per branch
+type BranchSelector interface {
+	SelectBranch(name string) error
+}
+
+// MetadataReporter is implemented by the driver which describes where the version is stored
+type MetadataReporter interface {
+	Metadata() []resource.MetadataField
+}
+
+// BuildRecorder is implemented by the driver which records the Concourse build in the history
+type BuildRecorder interface {
+	SetBuild(resource.BuildMetadata)
+}
+
+// WorkDirSetter is implemented by the driver which writes the files such as the clone locally.
+// The drivers in the same process must be given different directories.
+type WorkDirSetter interface {
+	SetWorkDir(dir string)
+}
+
+// Getter is implemented by the driver which can read the current version
+type Getter interface {
+	Get(context.Context) (string, error)
+}
+
+// Initializer is implemented by the driver which can store the initial version.
+// Init reports whether the version is stored newly.
+type Initializer interface {
+	Init(context.Context) (bool, error)
+}
+
+// Historian is implemented by the driver which can list the past versions.
+// The records are ordered from newest to oldest, and limit <= 0 means no limit.
+type Historian interface {
+	History(ctx context.Context, limit int) ([]Record, error)
+}
+
+// VersionFinder is implemented by the driver which can look up the version without listing all the history.
+// It returns ErrNotInHistory if the version has never been stored,
+// and the nil record without error if the driver can not tell, e.g. the history is truncated.
+type VersionFinder interface {
+	FindVersion(ctx context.Context, version string) (*Record, error)
+}
+
+// ErrNotInHistory is returned by VersionFinder if the version has never been stored
+var ErrNotInHistory = errors.New("not found in the history")
+
+// Record represents the version stored in the past
+type Record struct {
+	Version string `json:"version"`
+	// Previous and Operation are known if the driver keeps the audit log
+	Previous  string `json:"previous,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	// Commit is empty if the record is read from the audit log,
+	// which is written in the commit of the version
+	Commit   string    `json:"commit,omitempty"`
+	Author   string    `json:"author,omitempty"`
+	BuildURL string    `json:"build_url,omitempty"`
+	Time     time.Time `json:"time"`
 }
 
 // FromSource returns driver based on source configuration
 func FromSource(source resource.Source) (Driver, error) {
-	if source.InitialVersion == "" {
-		source.InitialVersion = "0"
-	}
-
-	switch source.Driver {
-	case resource.DriverUnspecified:
-		return nil, fmt.Errorf("driver is empty")
-	case resource.DriverGit:
-		return &GitDriver{
-			InitialVersion: source.InitialVersion,
-
-			URI:           source.URI,
-			Branch:        source.Branch,
-			PrivateKey:    source.PrivateKey,
-			Username:      source.Username,
-			Password:      source.Password,
-			File:          source.File,
-			GitUser:       source.GitUser,
-			CommitMessage: source.CommitMessage,
-
-			Runner: &resource.ExCommand{
-				Stdout: new(bytes.Buffer),
-				Stderr: new(bytes.Buffer),
-			},
-		}, nil
+	factory, err := lookup(source.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	source.InitialVersion = source.InitialVersionOrDefault()
+
+	config := factory.NewConfig()
+	if err := DecodeConfig(source, config); err != nil {
+		return nil, err
+	}
+	return factory.New(source, config)
+}
+
+// SelectBranch makes the driver operate the version of the branch
+func SelectBranch(d Driver, name string) error {
+	bs, ok := d.(BranchSelector)
+	if !ok {
+		return &Error{Kind: KindInvalidConfig, Op: "selecting branch", Err: errors.New("the driver does not support branch")}
+	}
+	return bs.SelectBranch(name)
+}
 
+// SetWorkDir makes the driver write its files under dir, if it writes any
+func SetWorkDir(d Driver, dir string) {
+	if s, ok := d.(WorkDirSetter); ok {
+		s.SetWorkDir(dir)
+	}
+}
+
+// FindVersion returns the record which stored the version in the history of the driver.
+// The record is nil if the driver does not keep the history or can not tell,
+// or the version is the initial version which is provided before any version is stored.
+func FindVersion(ctx context.Context, d Driver, version, initialVersion string) (*Record, error) {
+	var record *Record
+	var err error
+	switch f := d.(type) {
+	case VersionFinder:
+		record, err = f.FindVersion(ctx, version)
+	case Historian:
+		record, err = findInHistory(ctx, f, version)
 	default:
-		return nil, fmt.Errorf("unknown driver: %s", source.Driver)
+		return nil, nil
+	}
+	if errors.Is(err, ErrNotInHistory) {
+		if version == initialVersion {
+			return nil, nil
+		}
+		return nil, &Error{Kind: KindVersionNotFound, Op: "reading history", Err: fmt.Errorf("version %s is %w", version, err)}
+	}
+	return record, err
+}
+
+// findInHistory returns the newest record of the version in all the history
+func findInHistory(ctx context.Context, h Historian, version string) (*Record, error) {
+	records, err := h.History(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.Version == version {
+			return &r, nil
+		}
+	}
+	return nil, ErrNotInHistory
+}
+
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %v", err)
 	}
+	return d, nil
 }
@@ -0,0 +1,182 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+// ErrorKind classifies the failure of the driver
+type ErrorKind string
+
+const (
+	// KindUnknown is the failure which is not classified
+	KindUnknown ErrorKind = "unknown"
+	// KindAuth is the failure of authentication or authorization
+	KindAuth ErrorKind = "auth"
+	// KindConflict is the transient failure caused by a concurrent update
+	KindConflict ErrorKind = "conflict"
+	// KindNotFound is the failure caused by missing repository or branch
+	KindNotFound ErrorKind = "not_found"
+	// KindVersionNotFound is the failure caused by the version which has never been stored, e.g. the pinned one
+	KindVersionNotFound ErrorKind = "version_not_found"
+	// KindInvalidVersion is the failure caused by the version which can not be parsed
+	KindInvalidVersion ErrorKind = "invalid_version"
+	// KindRemoteRejected is the permanent failure that the remote refused the update
+	KindRemoteRejected ErrorKind = "remote_rejected"
+	// KindNetwork is the failure of connecting to the remote
+	KindNetwork ErrorKind = "network"
+	// KindInvalidConfig is the failure caused by the source or the parameters
+	KindInvalidConfig ErrorKind = "invalid_config"
+)
+
+var hints = map[ErrorKind]string{
+	KindAuth:            "check the credentials (private_key, username/password or token) and their permissions",
+	KindConflict:        "another pipeline updated the version concurrently; increase max_retries if this persists",
+	KindNotFound:        "check that uri, branch and file exist and are accessible with the credentials",
+	KindVersionNotFound: "the version was never stored; unpin it or check initial_version",
+	KindInvalidVersion:  "the version must be valid in the scheme; check scheme, initial_version and the content of the version file",
+	KindRemoteRejected:  "the remote refused the push; check branch protection rules such as required signatures",
+	KindNetwork:         "check the connectivity to the remote, ca_certs, skip_ssl_verification and https_proxy",
+	KindInvalidConfig:   "fix the problems above in source or params of the resource",
+}
+
+// Error represents the classified failure of the driver
+type Error struct {
+	Kind   ErrorKind
+	Op     string
+	Detail string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := e.Op
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	if detail := strings.TrimSpace(e.Detail); detail != "" {
+		msg = fmt.Sprintf("%s, detail: %s", msg, detail)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Hint returns the suggestion to resolve the failure
+func (e *Error) Hint() string {
+	return hints[e.Kind]
+}
+
+// KindOf returns the kind of the error, or KindUnknown if it is not classified
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindUnknown
+}
+
+var gitOutputKinds = []struct {
+	kind     ErrorKind
+	patterns []string
+}{
+	{KindRemoteRejected, []string{pushRemoteRejectedString}},
+	{KindConflict, []string{pushRejectedString, falsePushString}},
+	{KindAuth, []string{
+		"Authentication failed",
+		"could not read Username",
+		"could not read Password",
+		"Permission denied",
+		"Access denied",
+		"The requested URL returned error: 401",
+		"The requested URL returned error: 403",
+	}},
+	{KindNotFound, []string{
+		"Repository not found",
+		"not found in upstream origin",
+		"couldn't find remote ref",
+		"does not appear to be a git repository",
+		"The requested URL returned error: 404",
+	}},
+	{KindNetwork, []string{
+		"Could not resolve host",
+		"Connection refused",
+		"Connection timed out",
+		"Operation timed out",
+		"SSL certificate problem",
+		"Failed to connect",
+		"unable to access",
+	}},
+}
+
+// gitError classifies the failure of the git command by its output
+func gitError(op string, err error, output string) *Error {
+	kind := KindUnknown
+	// the command is killed when it hangs up
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: KindNetwork, Op: op, Detail: output, Err: err}
+	}
+	for _, k := range gitOutputKinds {
+		for _, p := range k.patterns {
+			if strings.Contains(output, p) {
+				kind = k.kind
+				break
+			}
+		}
+		if kind != KindUnknown {
+			break
+		}
+	}
+	return &Error{Kind: kind, Op: op, Detail: output, Err: err}
+}
+
+// ErrorFormatJSON is the value of error_format to emit machine-readable error record
+const ErrorFormatJSON = "json"
+
+// ErrorRecord is the machine-readable representation of the failure
+type ErrorRecord struct {
+	Doing string    `json:"doing"`
+	Kind  ErrorKind `json:"kind"`
+	Error string    `json:"error"`
+	Hint  string    `json:"hint,omitempty"`
+}
+
+// NewErrorRecord returns the error record of the failure
+func NewErrorRecord(doing string, err error) ErrorRecord {
+	record := ErrorRecord{
+		Doing: doing,
+		Kind:  KindOf(err),
+		Error: err.Error(),
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		record.Hint = e.Hint()
+	}
+	return record
+}
+
+// ReportError writes the failure to w for the commands, whose secrets are masked by redactor.
+// If format is "json", the error record follows in a single line.
+func ReportError(w io.Writer, redactor *resource.Redactor, format string, doing string, err error) {
+	w = redactor.Writer(w)
+	record := NewErrorRecord(doing, err)
+	// the secrets are masked before encoding, since the escaped ones do not match them
+	record.Error = redactor.Redact(record.Error)
+	fmt.Fprintf(w, "error %s: %v\n", doing, err)
+	if record.Hint != "" {
+		fmt.Fprintf(w, "hint: %s\n", record.Hint)
+	}
+	if format == ErrorFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(record)
+	}
+}
@@ -0,0 +1,77 @@
+package driver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+	. "github.com/cappyzawa/romver-resource/driver"
+)
+
+var _ = Describe("Errors", func() {
+	Describe("KindOf()", func() {
+		It("returns the kind of wrapped error", func() {
+			err := fmt.Errorf("bumping: %w", &Error{Kind: KindAuth, Op: "git clone"})
+			Expect(KindOf(err)).To(Equal(KindAuth))
+		})
+		It("returns unknown for unclassified error", func() {
+			Expect(KindOf(errors.New("error"))).To(Equal(KindUnknown))
+		})
+	})
+	Describe("ReportError()", func() {
+		var (
+			buf      *bytes.Buffer
+			redactor *resource.Redactor
+			format   string
+			err      error
+		)
+		BeforeEach(func() {
+			buf = new(bytes.Buffer)
+			redactor = nil
+			format = ""
+			err = &Error{Kind: KindRemoteRejected, Op: "git push", Detail: "[remote rejected]"}
+		})
+		JustBeforeEach(func() {
+			ReportError(buf, redactor, format, "bumping version", err)
+		})
+		It("writes the error with the hint", func() {
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(Equal("error bumping version: git push, detail: [remote rejected]"))
+			Expect(lines[1]).To(HavePrefix("hint: "))
+		})
+		Context("when format is json", func() {
+			BeforeEach(func() {
+				format = ErrorFormatJSON
+			})
+			It("writes the error record at the last line", func() {
+				lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+				Expect(lines).To(HaveLen(3))
+				var record ErrorRecord
+				Expect(json.Unmarshal([]byte(lines[2]), &record)).To(Succeed())
+				Expect(record.Doing).To(Equal("bumping version"))
+				Expect(record.Kind).To(Equal(KindRemoteRejected))
+				Expect(record.Hint).NotTo(BeEmpty())
+			})
+			Context("when the secret is escaped in JSON", func() {
+				BeforeEach(func() {
+					redactor = resource.NewRedactor(`pa"ss\word`)
+					err = &Error{Kind: KindAuth, Op: "git clone", Detail: `password pa"ss\word is wrong`}
+				})
+				It("masks it in the error record as well", func() {
+					Expect(buf.String()).NotTo(ContainSubstring(`ss\`))
+					lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+					var record ErrorRecord
+					Expect(json.Unmarshal([]byte(lines[2]), &record)).To(Succeed())
+					Expect(record.Error).To(Equal("git clone, detail: password ((redacted)) is wrong"))
+				})
+			})
+		})
+	})
+})
@@ -1,5 +1,7 @@
 package driver
 
+import "time"
+
 var (
 	ExportGitSetUpAuth             = (*GitDriver).setUpAuth
 	ExportGitIsPrivateKeyEncypted  = (*GitDriver).isPrivateKeyEncrypted
@@ -8,16 +10,19 @@ var (
 	ExportGitSetupRepo             = (*GitDriver).setUpRepo
 	ExportGitReadVersion           = (*GitDriver).readVersion
 	ExportGitWriteVersion          = (*GitDriver).writeVersion
-
-	ExportGitRepoDir        = gitRepoDir
-	ExportGitPrivateKeyPATH = privateKeyPATH
-	ExportGitNetRcPATH      = netRcPATH
+	ExportGitCommand               = (*GitDriver).gitCommand
+	ExportGitSetUpSigning          = (*GitDriver).setUpSigning
+	ExportBackoff                  = backoff
 )
 
-func SetGitRepoDir(path string) (resetFunc func()) {
-	var tmp string
-	tmp, gitRepoDir = gitRepoDir, path
+func SetGitRepoDir(gd *GitDriver, path string) {
+	gd.files().repoDir = path
+}
+
+func SetRetryDelay(d time.Duration) (resetFunc func()) {
+	tmpBase, tmpMax := retryBaseDelay, retryMaxDelay
+	retryBaseDelay, retryMaxDelay = d, d
 	return func() {
-		gitRepoDir = tmp
+		retryBaseDelay, retryMaxDelay = tmpBase, tmpMax
 	}
 }
@@ -1,36 +1,247 @@
 package driver
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/mail"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	resource "github.com/cappyzawa/romver-resource"
 )
 
-var (
-	gitRepoDir     string
-	privateKeyPATH string
-	netRcPATH      string
-)
-
 var ErrEncryptedKey = errors.New("private keys with passphrases are not supported")
 
 func init() {
-	gitRepoDir = filepath.Join(os.TempDir(), "romver-git-repo")
-	privateKeyPATH = filepath.Join(os.TempDir(), "private-key")
-	netRcPATH = filepath.Join(os.Getenv("HOME"), ".netrc")
+	Register(resource.DriverGit, Factory{
+		NewConfig: func() interface{} {
+			return &GitConfig{}
+		},
+		New: newGitDriver,
+	})
+}
+
+// gitPaths are the locations of the clone, the credentials and HOME for the commands
+type gitPaths struct {
+	repoDir       string
+	privateKey    string
+	netRc         string
+	caCerts       string
+	signingKey    string
+	sshSigningKey string
+
+	// home is HOME for the commands, empty means inheriting it
+	home string
+}
+
+func newGitPaths(tmpDir, homeDir string) *gitPaths {
+	return &gitPaths{
+		repoDir:       filepath.Join(tmpDir, "romver-git-repo"),
+		privateKey:    filepath.Join(tmpDir, "private-key"),
+		netRc:         filepath.Join(homeDir, ".netrc"),
+		caCerts:       filepath.Join(tmpDir, "ca-certs.pem"),
+		signingKey:    filepath.Join(tmpDir, "signing-key.asc"),
+		sshSigningKey: filepath.Join(tmpDir, "ssh-signing-key"),
+	}
+}
+
+// files returns the locations of the files which the driver writes
+func (gd *GitDriver) files() *gitPaths {
+	if gd.paths == nil {
+		if gd.WorkDir == "" {
+			gd.paths = newGitPaths(os.TempDir(), os.Getenv("HOME"))
+		} else {
+			gd.paths = newGitPaths(gd.WorkDir, gd.WorkDir)
+			gd.paths.home = gd.WorkDir
+		}
+	}
+	return gd.paths
+}
+
+// SetWorkDir makes the driver write the clone, the credentials and the global git config under dir
+func (gd *GitDriver) SetWorkDir(dir string) {
+	gd.WorkDir = dir
+	gd.paths = nil
+}
+
+// withHome applies HOME of the work directory to the command
+func (gd *GitDriver) withHome(cmd *exec.Cmd) *exec.Cmd {
+	if home := gd.files().home; home != "" {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "HOME="+home, "GNUPGHOME="+filepath.Join(home, ".gnupg"))
+	}
+	return cmd
+}
+
+// GitConfig represents the source configuration for git driver
+type GitConfig struct {
+	URI           string `json:"uri" romver:"required"`
+	Branch        string `json:"branch" romver:"required"`
+	PrivateKey    string `json:"private_key" romver:"secret"`
+	Username      string `json:"username"`
+	Password      string `json:"password" romver:"secret"`
+	File          string `json:"file"`
+	FileTemplate  string `json:"file_template"`
+	CreateBranch  bool   `json:"create_branch"`
+	Counter       string `json:"counter"`
+	GitUser       string `json:"git_user"`
+	Depth         string `json:"depth"`
+	CommitMessage string `json:"commit_message"`
+	MaxRetries    int    `json:"max_retries"`
+
+	CommitTrailers []string `json:"commit_trailers"`
+
+	SkipCIMarker           string `json:"skip_ci_marker"`
+	AvoidCommitOnUnchanged bool   `json:"avoid_commit_on_unchanged"`
+	AuditLog               string `json:"audit_log"`
+
+	Token               string `json:"token" romver:"secret"`
+	CACerts             string `json:"ca_certs"`
+	SkipSSLVerification bool   `json:"skip_ssl_verification"`
+	HTTPSProxy          string `json:"https_proxy"`
+	NoProxy             string `json:"no_proxy"`
+
+	SigningKey    string `json:"signing_key" romver:"secret"`
+	SSHSigningKey string `json:"ssh_signing_key" romver:"secret"`
+}
+
+// Secrets returns the values of the secret keys and the credentials encoded for basic auth
+func (c *GitConfig) Secrets() []string {
+	secrets := resource.SecretValues(c)
+	for _, p := range []string{c.Password, c.Token} {
+		if p != "" {
+			secrets = append(secrets, base64.StdEncoding.EncodeToString([]byte(c.Username+":"+p)))
+		}
+	}
+	return secrets
+}
+
+// Validate reports the problems of the configuration
+func (c *GitConfig) Validate() []string {
+	var problems []string
+	for _, p := range resource.MissingRequired(c) {
+		problems = append(problems, p+" for git driver")
+	}
+
+	if c.File == "" && c.FileTemplate == "" {
+		problems = append(problems, "one of file or file_template is required for git driver")
+	}
+	if c.FileTemplate != "" && !strings.Contains(c.FileTemplate, branchPlaceholder) {
+		problems = append(problems, fmt.Sprintf("file_template must contain %s: %q", branchPlaceholder, c.FileTemplate))
+	}
+	if c.AuditLog != "" && c.AuditLog == c.File {
+		problems = append(problems, "audit_log must be different from file")
+	}
+	if c.CommitMessage != "" {
+		if _, err := parseCommitTemplate("commit_message", c.CommitMessage); err != nil {
+			problems = append(problems, fmt.Sprintf("commit_message is not a valid template: %v", err))
+		}
+	}
+	for i, t := range c.CommitTrailers {
+		if _, err := parseCommitTemplate("commit_trailers", t); err != nil {
+			problems = append(problems, fmt.Sprintf("commit_trailers[%d] is not a valid template: %v", i, err))
+		} else if !trailerRegexp.MatchString(t) {
+			problems = append(problems, fmt.Sprintf("commit_trailers[%d] must be \"Key: value\": %q", i, t))
+		}
+	}
+	if c.Counter != "" && !counterNameRegexp.MatchString(c.Counter) {
+		problems = append(problems, fmt.Sprintf("counter must consist of letters, digits, '_', '.' and '-': %q", c.Counter))
+	}
+	if c.GitUser != "" {
+		if _, err := mail.ParseAddress(c.GitUser); err != nil {
+			problems = append(problems, fmt.Sprintf("git_user must be an RFC 5322 address such as \"name <email>\": %v", err))
+		}
+	}
+	if c.Depth != "" {
+		if d, err := strconv.Atoi(c.Depth); err != nil || d <= 0 {
+			problems = append(problems, fmt.Sprintf("depth must be a positive integer: %q", c.Depth))
+		}
+	}
+	if c.MaxRetries < 0 {
+		problems = append(problems, "max_retries must not be negative")
+	}
+	if c.SigningKey != "" && c.SSHSigningKey != "" {
+		problems = append(problems, "signing_key and ssh_signing_key are mutually exclusive")
+	}
+	if c.Password != "" && c.Username == "" {
+		problems = append(problems, "username is required when password is specified")
+	}
+	return problems
+}
+
+func newGitDriver(source resource.Source, config interface{}) (Driver, error) {
+	c := config.(*GitConfig)
+
+	timeout, err := parseTimeout(source.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	scheme, err := source.VersionScheme()
+	if err != nil {
+		return nil, err
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultMaxRetries
+	}
+
+	return &GitDriver{
+		InitialVersion: source.InitialVersion,
+		Scheme:         scheme,
+
+		URI:           c.URI,
+		Branch:        c.Branch,
+		PrivateKey:    c.PrivateKey,
+		Username:      c.Username,
+		Password:      c.Password,
+		File:          c.File,
+		FileTemplate:  c.FileTemplate,
+		CreateBranch:  c.CreateBranch,
+		Counter:       c.Counter,
+		GitUser:       c.GitUser,
+		Depth:         c.Depth,
+		CommitMessage: c.CommitMessage,
+		MaxRetries:    c.MaxRetries,
+		Timeout:       timeout,
+
+		CommitTrailers: c.CommitTrailers,
+
+		SkipCIMarker:           c.SkipCIMarker,
+		AvoidCommitOnUnchanged: c.AvoidCommitOnUnchanged,
+		AuditLog:               c.AuditLog,
+
+		Token:               c.Token,
+		CACerts:             c.CACerts,
+		SkipSSLVerification: c.SkipSSLVerification,
+		HTTPSProxy:          c.HTTPSProxy,
+		NoProxy:             c.NoProxy,
+
+		SigningKey:    c.SigningKey,
+		SSHSigningKey: c.SSHSigningKey,
+
+		Runner: resource.NewRedactor(c.Secrets()...).Runner(&resource.ExCommand{
+			Stdout: new(bytes.Buffer),
+			Stderr: new(bytes.Buffer),
+		}),
+	}, nil
 }
 
 // GitDriver accesses git
 type GitDriver struct {
 	InitialVersion string
+	// Scheme parses and increments the versions, defaults to resource.IntegerScheme
+	Scheme resource.Scheme
 
 	URI           string
 	Branch        string
@@ -38,57 +249,120 @@ type GitDriver struct {
 	Username      string
 	Password      string
 	File          string
+	FileTemplate  string
+	CreateBranch  bool
+	Counter       string
 	GitUser       string
 	Depth         string
 	CommitMessage string
+	MaxRetries    int
+	Timeout       time.Duration
+
+	CommitTrailers []string
+	// Build is exposed to the commit templates
+	Build map[string]string
+
+	SkipCIMarker           string
+	AvoidCommitOnUnchanged bool
+	// AuditLog is the JSON Lines file which records the changes in the same commits
+	AuditLog string
+
+	Token               string
+	CACerts             string
+	SkipSSLVerification bool
+	HTTPSProxy          string
+	NoProxy             string
+
+	SigningKey    string
+	SSHSigningKey string
+
+	// WorkDir is the directory of the clone, the credentials and the global git config.
+	// Empty means the temporary directory and HOME, which are dedicated to the resource in the container.
+	// The drivers used in the same process must have different directories.
+	WorkDir string
 
 	Runner resource.Runner
+
+	paths *gitPaths
+	// sshCommand is GIT_SSH_COMMAND for private_key
+	sshCommand   string
+	signingKeyID string
+	// branchAbsent is true while the branch does not exist on the remote
+	branchAbsent bool
+	// lastCommit is the commit which has the version written last
+	lastCommit string
+	// previous is the version replaced by the version written last
+	previous string
+	build    resource.BuildMetadata
 }
 
 // Bump increments version and pushs
-func (gd *GitDriver) Bump() (string, error) {
-	if err := gd.setUpAuth(); err != nil {
+func (gd *GitDriver) Bump(ctx context.Context) (string, error) {
+	return gd.BumpBy(ctx, 1)
+}
+
+// BumpBy increments version by n and pushs
+func (gd *GitDriver) BumpBy(ctx context.Context, n int) (string, error) {
+	if err := gd.requireFile(); err != nil {
 		return "", err
 	}
-	if err := gd.setUserInfo(); err != nil {
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return "", err
+	}
+	if err := gd.setUserInfo(ctx); err != nil {
+		return "", err
+	}
+	if err := gd.setUpSigning(ctx); err != nil {
 		return "", err
 	}
 
 	var newVersion string
-	for {
-		if err := gd.setUpRepo(); err != nil {
-			return "", err
+	err := retry(ctx, gd.MaxRetries, func() error {
+		if err := gd.setUpRepo(ctx); err != nil {
+			return err
 		}
 
 		currentVersion, exists, err := gd.readVersion()
 		if err != nil {
-			return "", err
+			return err
 		}
 		if !exists {
 			currentVersion = gd.InitialVersion
 		}
 
-		currentVersionInt, err := strconv.Atoi(currentVersion)
+		newVersion, err = gd.next(currentVersion, n)
 		if err != nil {
-			return "", nil
-		}
-
-		newVersion = strconv.Itoa(currentVersionInt + 1)
-		wrote, err := gd.writeVersion(newVersion)
-		if wrote {
-			break
+			return &Error{Kind: KindInvalidVersion, Op: "bumping current version", Err: err}
 		}
+		return gd.writeVersion(ctx, "bump", newVersion)
+	})
+	if err != nil {
+		return "", err
 	}
 	return newVersion, nil
 }
 
-// Check checks new version
-func (gd *GitDriver) Check(cursor string) ([]string, error) {
-	if err := gd.setUpAuth(); err != nil {
+// Check checks new version.
+// With file_template and without file, it reports InitialVersion since the branch is not known,
+// and get reads the version of the branch instead.
+func (gd *GitDriver) Check(ctx context.Context, cursor string) ([]string, error) {
+	// the file is resolved by the branch param of get and put
+	if gd.File == "" {
+		return []string{gd.InitialVersion}, nil
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
 		return nil, err
 	}
 
-	if err := gd.setUpRepo(); err != nil {
+	if err := gd.setUpRepo(ctx); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +378,7 @@ func (gd *GitDriver) Check(cursor string) ([]string, error) {
 		cursor = gd.InitialVersion
 	}
 
-	isCurrentGreater, err := gte(currentVersion, cursor)
+	isCurrentGreater, err := gte(gd.scheme(), currentVersion, cursor)
 	if err != nil {
 		return nil, err
 	}
@@ -117,62 +391,446 @@ func (gd *GitDriver) Check(cursor string) ([]string, error) {
 }
 
 // Set pushs version, but does not increment
-func (gd *GitDriver) Set(version string) error {
-	if err := gd.setUpAuth(); err != nil {
+func (gd *GitDriver) Set(ctx context.Context, version string) error {
+	if err := gd.requireFile(); err != nil {
+		return err
+	}
+	if err := gd.validate(version); err != nil {
 		return err
 	}
 
-	if err := gd.setUserInfo(); err != nil {
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return err
+	}
+
+	if err := gd.setUserInfo(ctx); err != nil {
+		return err
+	}
+
+	if err := gd.setUpSigning(ctx); err != nil {
 		return err
 	}
 
-	for {
-		if err := gd.setUpRepo(); err != nil {
+	return retry(ctx, gd.MaxRetries, func() error {
+		if err := gd.setUpRepo(ctx); err != nil {
+			return err
+		}
+		if gd.unchanged(version) {
+			gd.recordCommit(ctx)
+			return nil
+		}
+		return gd.writeVersion(ctx, "set", version)
+	})
+}
+
+// Get returns the current version, or the initial version if the file does not exist
+func (gd *GitDriver) Get(ctx context.Context) (string, error) {
+	if err := gd.requireFile(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return "", err
+	}
+	if err := gd.setUpRepo(ctx); err != nil {
+		return "", err
+	}
+
+	currentVersion, exists, err := gd.readVersion()
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return gd.InitialVersion, nil
+	}
+	return currentVersion, nil
+}
+
+// Init pushs the initial version if the file does not exist
+func (gd *GitDriver) Init(ctx context.Context) (bool, error) {
+	if err := gd.requireFile(); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return false, err
+	}
+	if err := gd.setUserInfo(ctx); err != nil {
+		return false, err
+	}
+	if err := gd.setUpSigning(ctx); err != nil {
+		return false, err
+	}
+
+	var created bool
+	err := retry(ctx, gd.MaxRetries, func() error {
+		created = false
+		if err := gd.setUpRepo(ctx); err != nil {
+			return err
+		}
+		_, exists, err := gd.readVersion()
+		if err != nil || exists {
+			return err
+		}
+		created = true
+		return gd.writeVersion(ctx, "init", gd.InitialVersion)
+	})
+	return created, err
+}
+
+// Update bumps or sets the versions of the targets in a single commit
+func (gd *GitDriver) Update(ctx context.Context, updates []Update) ([]Updated, error) {
+	if err := gd.requireFile(); err != nil {
+		return nil, err
+	}
+	own := -1
+	for i, u := range updates {
+		if t := gd.target(u); own < 0 && t.File == gd.File && t.Counter == gd.Counter {
+			own = i
+		}
+		if !u.Bump {
+			if err := gd.validate(u.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if own < 0 {
+		return nil, &Error{Kind: KindInvalidConfig, Op: "updating counters", Err: fmt.Errorf("counters must include %s of the source", gd.location())}
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return nil, err
+	}
+	if err := gd.setUserInfo(ctx); err != nil {
+		return nil, err
+	}
+	if err := gd.setUpSigning(ctx); err != nil {
+		return nil, err
+	}
+
+	var versions []Updated
+	err := retry(ctx, gd.MaxRetries, func() error {
+		if err := gd.setUpRepo(ctx); err != nil {
 			return err
 		}
 
-		wrote, err := gd.writeVersion(version)
+		versions = make([]Updated, len(updates))
+		var files, labels []string
+		var changes []Change
+		var entries []AuditEntry
+		for i, u := range updates {
+			target := gd.target(u)
+			if err := target.requireFile(); err != nil {
+				return err
+			}
+			version := u.Version
+			// the broken version is overwritten unless it is bumped
+			previous, exists, err := target.readVersion()
+			if err != nil && u.Bump {
+				return err
+			}
+			if u.Bump {
+				current := previous
+				if !exists {
+					current = gd.InitialVersion
+				}
+				if version, err = gd.next(current, 1); err != nil {
+					return &Error{Kind: KindInvalidVersion, Op: "bumping current version of " + target.label(), Err: err}
+				}
+			}
+			versions[i] = Updated{Label: target.location(), Version: version, Own: i == own}
+			if i == own {
+				gd.previous = previous
+				if !exists {
+					gd.previous = gd.InitialVersion
+				}
+			}
+			if target.unchanged(version) {
+				continue
+			}
+			if err := target.writeFile(version); err != nil {
+				return err
+			}
+			files = append(files, target.File)
+			labels = append(labels, fmt.Sprintf("%s to %s", target.label(), version))
+			changes = append(changes, Change{Name: target.label(), Previous: previous, Version: version})
+			operation := "set"
+			if u.Bump {
+				operation = "bump"
+			}
+			entries = append(entries, AuditEntry{Operation: operation, File: target.File, Counter: target.Counter, Previous: previous, Version: version})
+		}
+
+		if len(files) == 0 {
+			return nil
+		}
+		if err := gd.writeAuditLog(ctx, entries); err != nil {
+			return err
+		}
+		files = gd.withAuditLog(files...)
+		commitMessage, err := gd.commitMessage(changes, "bump "+strings.Join(labels, ", "))
 		if err != nil {
 			return err
 		}
+		return gd.commit(ctx, files, commitMessage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// target returns the driver for the file and the counter of the update
+func (gd *GitDriver) target(u Update) *GitDriver {
+	t := *gd
+	if u.File != "" {
+		t.File = u.File
+		t.Counter = u.Counter
+	} else if u.Counter != "" {
+		t.Counter = u.Counter
+	}
+	return &t
+}
+
+// branchPlaceholder is replaced with the name of the branch in file_template
+const branchPlaceholder = "%branch%"
+
+// SelectBranch resolves file_template with the name of the branch
+func (gd *GitDriver) SelectBranch(name string) error {
+	if gd.FileTemplate == "" {
+		return &Error{Kind: KindInvalidConfig, Op: "selecting branch", Err: errors.New("file_template is not specified")}
+	}
+	file := path.Clean(strings.Replace(gd.FileTemplate, branchPlaceholder, name, -1))
+	// git does not allow ".." in the name of branches, which would escape from the directory
+	if name == "" || strings.Contains(name, "..") || path.IsAbs(file) {
+		return &Error{Kind: KindInvalidConfig, Op: "selecting branch", Err: fmt.Errorf("invalid branch: %q", name)}
+	}
+	gd.File = file
+	return nil
+}
+
+// requireFile returns the error if file_template is not resolved
+func (gd *GitDriver) requireFile() error {
+	if gd.File == "" {
+		return &Error{Kind: KindInvalidConfig, Op: "resolving file", Err: errors.New("branch param is required for file_template")}
+	}
+	return nil
+}
+
+// label returns the name of the counter, or the file if it has a single version
+func (gd *GitDriver) label() string {
+	if gd.Counter != "" {
+		return gd.Counter
+	}
+	return gd.File
+}
+
+// location returns the file, and the counter in it if any, e.g. "versions.yml:web"
+func (gd *GitDriver) location() string {
+	if gd.Counter != "" {
+		return gd.File + ":" + gd.Counter
+	}
+	return gd.File
+}
+
+// History returns the versions in the commits which changed the file
+func (gd *GitDriver) History(ctx context.Context, limit int) ([]Record, error) {
+	if err := gd.requireFile(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return nil, err
+	}
+	if err := gd.setUpRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	if gd.branchAbsent {
+		return nil, nil
+	}
+	if gd.AuditLog != "" {
+		return gd.readAuditLog(limit)
+	}
+
+	var records []Record
+	err := gd.walkLog(ctx, func(r Record) bool {
+		records = append(records, r)
+		return limit <= 0 || len(records) < limit
+	})
+	return records, err
+}
+
+// FindVersion returns the record which stored the version.
+// The commits are read from newest to oldest until the version is found,
+// and the git log is also read if audit_log does not have the version, e.g. it is enabled later.
+func (gd *GitDriver) FindVersion(ctx context.Context, version string) (*Record, error) {
+	// the file is resolved by the branch param
+	if gd.File == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := gd.withTimeout(ctx)
+	defer cancel()
+
+	if err := gd.setUpAuth(ctx); err != nil {
+		return nil, err
+	}
+	if err := gd.setUpRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	if gd.branchAbsent {
+		return nil, ErrNotInHistory
+	}
+	if gd.AuditLog != "" {
+		records, err := gd.readAuditLog(0)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if r.Version == version {
+				return &r, nil
+			}
+		}
+	}
 
-		if wrote {
-			break
+	var found *Record
+	err := gd.walkLog(ctx, func(r Record) bool {
+		if r.Version == version {
+			found = &r
+			return false
 		}
+		return true
+	})
+	if err != nil || found != nil {
+		return found, err
 	}
 
+	// the older commits are not fetched with depth
+	gitRevParse := gd.gitCommand("rev-parse", "--is-shallow-repository")
+	gitRevParse.Dir = gd.files().repoDir
+	if output, err := gd.Runner.CombinedOutput(ctx, gitRevParse); err == nil && strings.TrimSpace(string(output)) == "true" {
+		return nil, nil
+	}
+	return nil, ErrNotInHistory
+}
+
+// walkLog calls fn with the versions in the commits which changed the file from newest to oldest
+// until fn returns false. With counter, the commits which did not change the counter are skipped.
+func (gd *GitDriver) walkLog(ctx context.Context, fn func(Record) bool) error {
+	gitLog := gd.gitCommand("log", "--format=%H%x1f%an <%ae>%x1f%aI", "--", gd.File)
+	gitLog.Dir = gd.files().repoDir
+	logOutput, err := gd.Runner.CombinedOutput(ctx, gitLog)
+	if err != nil {
+		return gitError("git log", err, string(logOutput))
+	}
+
+	// the record of the counter is pending until the older commit shows that it changed the counter
+	var pending *Record
+	for _, line := range strings.Split(strings.TrimSpace(string(logOutput)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return err
+		}
+
+		gitShow := gd.gitCommand("show", fields[0]+":"+gd.File)
+		gitShow.Dir = gd.files().repoDir
+		showOutput, err := gd.Runner.CombinedOutput(ctx, gitShow)
+		if err != nil {
+			// the file is deleted in the commit
+			continue
+		}
+		version, exists, err := gd.parseVersion(showOutput)
+		if err != nil || !exists {
+			continue
+		}
+		r := Record{
+			Version: version,
+			Commit:  fields[0],
+			Author:  fields[1],
+			Time:    t,
+		}
+
+		if gd.Counter == "" {
+			if !fn(r) {
+				return nil
+			}
+			continue
+		}
+		if pending != nil && pending.Version != r.Version && !fn(*pending) {
+			return nil
+		}
+		pending = &r
+	}
+	if pending != nil {
+		fn(*pending)
+	}
 	return nil
 }
 
-func (gd *GitDriver) setUpAuth() error {
-	if _, err := os.Stat(netRcPATH); err != nil {
+// withTimeout returns the context which is canceled when the timeout elapses
+func (gd *GitDriver) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if gd.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, gd.Timeout)
+}
+
+func (gd *GitDriver) setUpAuth(ctx context.Context) error {
+	if _, err := os.Stat(gd.files().netRc); err != nil {
 		if !os.IsNotExist(err) {
 			return err
 		}
 	} else {
-		if err := os.Remove(netRcPATH); err != nil {
+		if err := os.Remove(gd.files().netRc); err != nil {
 			return err
 		}
 	}
 
 	if gd.PrivateKey != "" {
-		if err := gd.setUpKey(); err != nil {
+		if err := gd.setUpKey(ctx); err != nil {
 			return err
 		}
 	}
 
 	if gd.Username != "" && gd.Password != "" {
-		if err := gd.setUpUsernamePassword(); err != nil {
+		if err := gd.setUpUsernamePassword(ctx); err != nil {
+			return err
+		}
+	}
+
+	if gd.CACerts != "" {
+		if err := ioutil.WriteFile(gd.files().caCerts, []byte(gd.CACerts+"\n"), 0600); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (gd *GitDriver) setUpKey() error {
-	if _, err := os.Stat(privateKeyPATH); err != nil {
+func (gd *GitDriver) setUpKey(ctx context.Context) error {
+	if _, err := os.Stat(gd.files().privateKey); err != nil {
 		if os.IsNotExist(err) {
-			if err := ioutil.WriteFile(privateKeyPATH, []byte(gd.PrivateKey+"\n"), 0600); err != nil {
+			if err := ioutil.WriteFile(gd.files().privateKey, []byte(gd.PrivateKey+"\n"), 0600); err != nil {
 				return err
 			}
 		} else {
@@ -180,24 +838,25 @@ func (gd *GitDriver) setUpKey() error {
 		}
 	}
 
-	if gd.isPrivateKeyEncrypted(privateKeyPATH) {
-		return ErrEncryptedKey
+	if gd.isPrivateKeyEncrypted(ctx, gd.files().privateKey) {
+		return &Error{Kind: KindAuth, Op: "setting up private key", Err: ErrEncryptedKey}
 	}
-	return os.Setenv("GIT_SSH_COMMAND", "ssh -o StrictHostKeyChecking=no -i "+privateKeyPATH)
+	gd.sshCommand = "ssh -o StrictHostKeyChecking=no -i " + gd.files().privateKey
+	return nil
 }
 
-func (gd *GitDriver) isPrivateKeyEncrypted(path string) bool {
+func (gd *GitDriver) isPrivateKeyEncrypted(ctx context.Context, path string) bool {
 	passphrases := ``
-	cmd := exec.Command(`ssh-keygen`, `-y`, `-f`, path, `-P`, passphrases)
-	err := gd.Runner.Run(cmd)
+	cmd := gd.withHome(exec.Command(`ssh-keygen`, `-y`, `-f`, path, `-P`, passphrases))
+	err := gd.Runner.Run(ctx, cmd)
 	return err != nil
 }
 
-func (gd *GitDriver) setUpUsernamePassword() error {
-	if _, err := os.Stat(netRcPATH); err != nil {
+func (gd *GitDriver) setUpUsernamePassword(ctx context.Context) error {
+	if _, err := os.Stat(gd.files().netRc); err != nil {
 		if os.IsNotExist(err) {
 			content := fmt.Sprintf("default login %s password %s", gd.Username, gd.Password)
-			if err := ioutil.WriteFile(netRcPATH, []byte(content), 0600); err != nil {
+			if err := ioutil.WriteFile(gd.files().netRc, []byte(content), 0600); err != nil {
 				return err
 			}
 		} else {
@@ -207,7 +866,7 @@ func (gd *GitDriver) setUpUsernamePassword() error {
 	return nil
 }
 
-func (gd *GitDriver) setUserInfo() error {
+func (gd *GitDriver) setUserInfo(ctx context.Context) error {
 	if gd.GitUser == "" {
 		return nil
 	}
@@ -218,60 +877,220 @@ func (gd *GitDriver) setUserInfo() error {
 	}
 
 	if user.Name != "" {
-		gitName := exec.Command("git", "config", "--global", "user.name", user.Name)
-		if err := gd.Runner.Run(gitName); err != nil {
-			return fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+		gitName := gd.gitCommand("config", "--global", "user.name", user.Name)
+		if err := gd.Runner.Run(ctx, gitName); err != nil {
+			return gitError("git config user.name", err, gd.stderr())
 		}
 	}
 
-	gitEmail := exec.Command("git", "config", "--global", "user.email", user.Address)
-	if err := gd.Runner.Run(gitEmail); err != nil {
-		return fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+	gitEmail := gd.gitCommand("config", "--global", "user.email", user.Address)
+	if err := gd.Runner.Run(ctx, gitEmail); err != nil {
+		return gitError("git config user.email", err, gd.stderr())
 	}
 	return nil
 }
 
-func (gd *GitDriver) setUpRepo() error {
-	if _, err := os.Stat(gitRepoDir); err != nil {
-		gitClone := exec.Command("git", "clone", gd.URI, "--branch", gd.Branch)
+// setUpSigning imports the key so that commits and tags are signed
+func (gd *GitDriver) setUpSigning(ctx context.Context) error {
+	if gd.SigningKey != "" && gd.SSHSigningKey != "" {
+		return errors.New("signing_key and ssh_signing_key are mutually exclusive")
+	}
+
+	if gd.SSHSigningKey != "" {
+		return ioutil.WriteFile(gd.files().sshSigningKey, []byte(gd.SSHSigningKey+"\n"), 0600)
+	}
+
+	if gd.SigningKey == "" {
+		return nil
+	}
+	if err := ioutil.WriteFile(gd.files().signingKey, []byte(gd.SigningKey+"\n"), 0600); err != nil {
+		return err
+	}
+
+	gpgImport := gd.withHome(exec.Command("gpg", "--batch", "--import", gd.files().signingKey))
+	if err := gd.Runner.Run(ctx, gpgImport); err != nil {
+		return &Error{Kind: KindAuth, Op: "importing signing key", Detail: gd.stderr(), Err: err}
+	}
+
+	gpgShow := gd.withHome(exec.Command("gpg", "--batch", "--with-colons", "--import-options", "show-only", "--import", gd.files().signingKey))
+	showOutput, err := gd.Runner.CombinedOutput(ctx, gpgShow)
+	if err != nil {
+		return &Error{Kind: KindAuth, Op: "reading signing key", Detail: string(showOutput), Err: err}
+	}
+
+	// the first fingerprint belongs to the primary key
+	for _, line := range strings.Split(string(showOutput), "\n") {
+		fields := strings.Split(line, ":")
+		if fields[0] == "fpr" && len(fields) > 9 {
+			gd.signingKeyID = fields[9]
+			return nil
+		}
+	}
+	return &Error{Kind: KindAuth, Op: "reading signing key", Err: errors.New("fingerprint is not found")}
+}
+
+// gitCommand returns git command applied HTTP(S) settings via environment variables,
+// so that they are neither persisted in the repository nor shown in the arguments
+func (gd *GitDriver) gitCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+
+	var configs [][2]string
+	if gd.Token != "" {
+		configs = append(configs, [2]string{"http.extraHeader", "Authorization: " + gd.authorization()})
+	}
+	if gd.CACerts != "" {
+		configs = append(configs, [2]string{"http.sslCAInfo", gd.files().caCerts})
+	}
+	if gd.SkipSSLVerification {
+		configs = append(configs, [2]string{"http.sslVerify", "false"})
+	}
+	if gd.SSHSigningKey != "" {
+		configs = append(configs,
+			[2]string{"gpg.format", "ssh"},
+			[2]string{"user.signingKey", gd.files().sshSigningKey},
+		)
+	} else if gd.signingKeyID != "" {
+		configs = append(configs, [2]string{"user.signingKey", gd.signingKeyID})
+	}
+	if gd.SSHSigningKey != "" || gd.signingKeyID != "" {
+		configs = append(configs,
+			[2]string{"commit.gpgSign", "true"},
+			[2]string{"tag.gpgSign", "true"},
+		)
+	}
+
+	var env []string
+	if len(configs) != 0 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(configs)))
+		for i, c := range configs {
+			env = append(env,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, c[0]),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, c[1]),
+			)
+		}
+	}
+	if gd.sshCommand != "" {
+		env = append(env, "GIT_SSH_COMMAND="+gd.sshCommand)
+	}
+	if gd.HTTPSProxy != "" {
+		env = append(env, "https_proxy="+gd.HTTPSProxy, "HTTPS_PROXY="+gd.HTTPSProxy)
+	}
+	if gd.NoProxy != "" {
+		env = append(env, "no_proxy="+gd.NoProxy, "NO_PROXY="+gd.NoProxy)
+	}
+	if len(env) != 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return gd.withHome(cmd)
+}
+
+// authorization returns Authorization header value for the token.
+// If username is specified (e.g. "x-access-token" for GitHub App), basic auth is used
+func (gd *GitDriver) authorization() string {
+	if gd.Username != "" {
+		cred := base64.StdEncoding.EncodeToString([]byte(gd.Username + ":" + gd.Token))
+		return "Basic " + cred
+	}
+	return "Bearer " + gd.Token
+}
+
+func (gd *GitDriver) setUpRepo(ctx context.Context) error {
+	if gd.CreateBranch {
+		exists, err := gd.branchExists(ctx)
+		if err != nil {
+			return err
+		}
+		gd.branchAbsent = !exists
+		if !exists {
+			return gd.setUpOrphanRepo(ctx)
+		}
+	}
+
+	if _, err := os.Stat(gd.files().repoDir); err != nil {
+		gitClone := gd.gitCommand("clone", gd.URI, "--branch", gd.Branch)
 		if gd.Depth != "" {
 			gitClone.Args = append(gitClone.Args, "--depth", gd.Depth)
 		}
-		gitClone.Args = append(gitClone.Args, "--single-branch", gitRepoDir)
-		if err := gd.Runner.Run(gitClone); err != nil {
-			return fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+		gitClone.Args = append(gitClone.Args, "--single-branch", gd.files().repoDir)
+		if err := gd.Runner.Run(ctx, gitClone); err != nil {
+			return gitError("git clone", err, gd.stderr())
 		}
 	} else {
-		gitFetch := exec.Command("git", "fetch", "origin", gd.Branch)
-		gitFetch.Dir = gitRepoDir
-		if err := gd.Runner.Run(gitFetch); err != nil {
-			return fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+		gitFetch := gd.gitCommand("fetch", "origin", gd.Branch)
+		gitFetch.Dir = gd.files().repoDir
+		if err := gd.Runner.Run(ctx, gitFetch); err != nil {
+			return gitError("git fetch", err, gd.stderr())
 		}
 	}
 
-	gitCheckout := exec.Command("git", "reset", "--hard", "origin/"+gd.Branch)
-	gitCheckout.Dir = gitRepoDir
-	if err := gd.Runner.Run(gitCheckout); err != nil {
-		return fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+	gitCheckout := gd.gitCommand("reset", "--hard", "origin/"+gd.Branch)
+	gitCheckout.Dir = gd.files().repoDir
+	if err := gd.Runner.Run(ctx, gitCheckout); err != nil {
+		return gitError("git reset", err, gd.stderr())
+	}
+	return nil
+}
+
+// branchExists reports whether the branch exists on the remote
+func (gd *GitDriver) branchExists(ctx context.Context) (bool, error) {
+	gitLsRemote := gd.gitCommand("ls-remote", "--heads", gd.URI, "refs/heads/"+gd.Branch)
+	output, err := gd.Runner.CombinedOutput(ctx, gitLsRemote)
+	if err != nil {
+		return false, gitError("git ls-remote", err, string(output))
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// setUpOrphanRepo prepares the repository whose branch has no commits,
+// so that the first push creates the branch with only the version file.
+func (gd *GitDriver) setUpOrphanRepo(ctx context.Context) error {
+	if _, err := os.Stat(gd.files().repoDir); err != nil {
+		gitInit := gd.gitCommand("init", gd.files().repoDir)
+		if err := gd.Runner.Run(ctx, gitInit); err != nil {
+			return gitError("git init", err, gd.stderr())
+		}
+		gitRemote := gd.gitCommand("remote", "add", "origin", gd.URI)
+		gitRemote.Dir = gd.files().repoDir
+		if err := gd.Runner.Run(ctx, gitRemote); err != nil {
+			return gitError("git remote add", err, gd.stderr())
+		}
+	}
+
+	gitSymbolicRef := gd.gitCommand("symbolic-ref", "HEAD", "refs/heads/"+gd.Branch)
+	gitSymbolicRef.Dir = gd.files().repoDir
+	if err := gd.Runner.Run(ctx, gitSymbolicRef); err != nil {
+		return gitError("git symbolic-ref", err, gd.stderr())
 	}
 	return nil
 }
 
 func (gd *GitDriver) readVersion() (string, bool, error) {
-	var currentVersion string
-	versionFile, err := os.Open(filepath.Join(gitRepoDir, gd.File))
+	content, err := ioutil.ReadFile(filepath.Join(gd.files().repoDir, gd.File))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", false, nil
 		}
 		return "", false, err
 	}
-	defer versionFile.Close()
+	return gd.parseVersion(content)
+}
 
-	if _, err := fmt.Fscanf(versionFile, "%s", &currentVersion); err != nil {
-		return "", false, err
+// parseVersion returns the version in the content of the file
+func (gd *GitDriver) parseVersion(content []byte) (string, bool, error) {
+	if gd.Counter != "" {
+		f, err := parseCounterFile(gd.File, content)
+		if err != nil {
+			return "", false, &Error{Kind: KindInvalidVersion, Op: "reading counter " + gd.Counter, Err: err}
+		}
+		version, exists := f.get(gd.Counter)
+		return version, exists, nil
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return "", false, io.EOF
 	}
-	return currentVersion, true, nil
+	return fields[0], true, nil
 }
 
 const (
@@ -281,66 +1100,175 @@ const (
 	pushRemoteRejectedString = "[remote rejected]"
 )
 
-func (gd *GitDriver) writeVersion(newVersion string) (bool, error) {
-	if err := ioutil.WriteFile(filepath.Join(gitRepoDir, gd.File), []byte(newVersion), 0644); err != nil {
-		return false, nil
+// writeVersion commits the version, and records the operation in audit_log
+func (gd *GitDriver) writeVersion(ctx context.Context, operation, newVersion string) error {
+	// the broken version is overwritten
+	previous, exists, _ := gd.readVersion()
+	gd.previous = previous
+	if !exists {
+		gd.previous = gd.InitialVersion
+	}
+	if err := gd.writeFile(newVersion); err != nil {
+		return err
+	}
+	entry := AuditEntry{Operation: operation, File: gd.File, Counter: gd.Counter, Previous: previous, Version: newVersion}
+	if err := gd.writeAuditLog(ctx, []AuditEntry{entry}); err != nil {
+		return err
 	}
 
-	gitAdd := exec.Command("git", "add", gd.File)
-	gitAdd.Dir = gitRepoDir
-	if err := gd.Runner.Run(gitAdd); err != nil {
-		return false, fmt.Errorf("error: %v, detail: %v", err, gd.Runner.Error())
+	defaultMessage := fmt.Sprintf("bump to %s", newVersion)
+	if gd.Counter != "" {
+		defaultMessage = fmt.Sprintf("bump %s to %s", gd.Counter, newVersion)
 	}
-	var commitMessage string
-	if gd.CommitMessage == "" {
-		commitMessage = fmt.Sprintf("bump to %s", newVersion)
-	} else {
-		commitMessage = strings.Replace(gd.CommitMessage, "%version%", newVersion, -1)
-		commitMessage = strings.Replace(gd.CommitMessage, "%file%", gd.File, -1)
+	commitMessage, err := gd.commitMessage([]Change{{Name: gd.label(), Previous: previous, Version: newVersion}}, defaultMessage)
+	if err != nil {
+		return err
 	}
+	return gd.commit(ctx, gd.withAuditLog(gd.File), commitMessage)
+}
 
-	gitCommit := exec.Command("git", "commit", "-m", commitMessage)
-	gitCommit.Dir = gitRepoDir
-	commitOutput, err := gd.Runner.CombinedOutput(gitCommit)
+// writeFile writes the version to the file in the clone
+func (gd *GitDriver) writeFile(newVersion string) error {
+	content, err := gd.versionContent(newVersion)
 	if err != nil {
-		return false, err
+		return err
 	}
-	if strings.Contains(string(commitOutput), nothingToCommitString) {
-		return true, nil
+	path := filepath.Join(gd.files().repoDir, gd.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
 	}
+	return ioutil.WriteFile(path, content, 0644)
+}
 
-	gitPush := exec.Command("git", "push", "origin", "HEAD:"+gd.Branch)
-	gitPush.Dir = gitRepoDir
+// commit commits the files and pushs the commit
+func (gd *GitDriver) commit(ctx context.Context, files []string, commitMessage string) error {
+	gitAdd := gd.gitCommand(append([]string{"add", "--"}, files...)...)
+	gitAdd.Dir = gd.files().repoDir
+	if err := gd.Runner.Run(ctx, gitAdd); err != nil {
+		return gitError("git add", err, gd.stderr())
+	}
 
-	pushOutput, err := gd.Runner.CombinedOutput(gitPush)
+	gitCommit := gd.gitCommand("commit", "-m", commitMessage)
+	gitCommit.Dir = gd.files().repoDir
+	commitOutput, err := gd.Runner.CombinedOutput(ctx, gitCommit)
+	if strings.Contains(string(commitOutput), nothingToCommitString) {
+		gd.recordCommit(ctx)
+		return nil
+	}
 	if err != nil {
-		return false, err
+		return gitError("git commit", err, string(commitOutput))
 	}
 
-	if strings.Contains(string(pushOutput), falsePushString) {
-		return false, nil
+	gitPush := gd.gitCommand("push", "origin", "HEAD:"+gd.Branch)
+	gitPush.Dir = gd.files().repoDir
+
+	// the push may not be applied even if git exits successfully
+	pushOutput, err := gd.Runner.CombinedOutput(ctx, gitPush)
+	pushErr := gitError("git push", err, string(pushOutput))
+	if err != nil || pushErr.Kind == KindConflict || pushErr.Kind == KindRemoteRejected {
+		return pushErr
 	}
+	gd.recordCommit(ctx)
+	return nil
+}
 
-	if strings.Contains(string(pushOutput), pushRejectedString) {
-		return false, nil
+// recordCommit records HEAD as the commit which has the version
+func (gd *GitDriver) recordCommit(ctx context.Context) {
+	gitRevParse := gd.gitCommand("rev-parse", "HEAD")
+	gitRevParse.Dir = gd.files().repoDir
+	if output, err := gd.Runner.CombinedOutput(ctx, gitRevParse); err == nil {
+		gd.lastCommit = strings.TrimSpace(string(output))
 	}
+}
 
-	if strings.Contains(string(pushOutput), pushRemoteRejectedString) {
-		return false, nil
+// unchanged reports whether the version is already stored and avoid_commit_on_unchanged is enabled
+func (gd *GitDriver) unchanged(version string) bool {
+	if !gd.AvoidCommitOnUnchanged {
+		return false
+	}
+	current, exists, err := gd.readVersion()
+	if err != nil || !exists {
+		return false
 	}
+	c, err := resource.CompareVersions(gd.scheme(), current, version)
+	return err == nil && c == 0
+}
 
-	return true, err
+// Metadata returns the location of the version, and the previous version and the commit of the version written last
+func (gd *GitDriver) Metadata() []resource.MetadataField {
+	metadata := []resource.MetadataField{
+		{Name: "branch", Value: gd.Branch},
+		{Name: "file", Value: gd.File},
+	}
+	if gd.Counter != "" {
+		metadata = append(metadata, resource.MetadataField{Name: "counter", Value: gd.Counter})
+	}
+	if gd.previous != "" {
+		metadata = append(metadata, resource.MetadataField{Name: "previous", Value: gd.previous})
+	}
+	if gd.lastCommit != "" {
+		metadata = append(metadata, resource.MetadataField{Name: "commit", Value: gd.lastCommit})
+	}
+	return metadata
 }
 
-func gte(current, cursor string) (bool, error) {
-	currentInt, err := strconv.Atoi(current)
-	if err != nil {
-		return false, err
+// versionContent returns the content of the file which has the version.
+// The other counters in the file are preserved.
+func (gd *GitDriver) versionContent(newVersion string) ([]byte, error) {
+	if gd.Counter == "" {
+		return []byte(newVersion), nil
+	}
+
+	path := filepath.Join(gd.files().repoDir, gd.File)
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
-	cursorInt, err := strconv.Atoi(cursor)
+	f, err := parseCounterFile(gd.File, content)
 	if err != nil {
-		return false, err
+		return nil, &Error{Kind: KindInvalidVersion, Op: "reading counter " + gd.Counter, Err: err}
+	}
+	f.set(gd.Counter, newVersion)
+	return f.bytes()
+}
+
+// scheme returns the scheme of the versions
+func (gd *GitDriver) scheme() resource.Scheme {
+	if gd.Scheme == nil {
+		return resource.IntegerScheme{}
+	}
+	return gd.Scheme
+}
+
+// validate returns invalid_version error if the version is not valid in the scheme
+func (gd *GitDriver) validate(version string) error {
+	if err := resource.ValidateVersion(gd.scheme(), version); err != nil {
+		return &Error{Kind: KindInvalidVersion, Op: "parsing version", Err: err}
 	}
+	return nil
+}
+
+// next returns the version bumped n times in the scheme
+func (gd *GitDriver) next(version string, n int) (string, error) {
+	return resource.NextVersion(gd.scheme(), version, n)
+}
 
-	return currentInt-cursorInt >= 0, nil
+// stderr returns the error output of the commands run by the runner
+func (gd *GitDriver) stderr() string {
+	if err := gd.Runner.Error(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+func gte(scheme resource.Scheme, current, cursor string) (bool, error) {
+	x, err := scheme.Parse(current)
+	if err != nil {
+		return false, &Error{Kind: KindInvalidVersion, Op: "parsing current version", Err: err}
+	}
+	y, err := scheme.Parse(cursor)
+	if err != nil {
+		return false, &Error{Kind: KindInvalidVersion, Op: "parsing cursor version", Err: err}
+	}
+	return scheme.Compare(x, y) >= 0, nil
 }
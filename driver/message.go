@@ -0,0 +1,116 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+// CommitMessageData is the data of commit_message and commit_trailers templates
+type CommitMessageData struct {
+	// Version is the new version, which is the first one of Changes
+	Version string
+	// Previous is the version before the change, empty if it was not stored
+	Previous string
+	File     string
+	Branch   string
+	Counter  string
+	Changes  []Change
+	// Build has the environment variables of the Concourse build, e.g. BUILD_JOB_NAME
+	Build     map[string]string
+	Timestamp time.Time
+}
+
+// Change represents the version changed in the commit
+type Change struct {
+	Name     string
+	Previous string
+	Version  string
+}
+
+// trailerRegexp matches the git trailer such as "Signed-off-by: name <email>"
+var trailerRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+: \S`)
+
+// parseCommitTemplate parses the template.
+// %version% and %file% are kept for the messages written before the template was introduced.
+func parseCommitTemplate(name, text string) (*template.Template, error) {
+	text = strings.NewReplacer("%version%", "{{.Version}}", "%file%", "{{.File}}").Replace(text)
+	return template.New(name).Option("missingkey=zero").Parse(text)
+}
+
+func renderCommitTemplate(name, text string, data CommitMessageData) (string, error) {
+	t, err := parseCommitTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// commitMessage returns the message of the commit for the changes.
+// defaultMessage is used if commit_message is not specified.
+func (gd *GitDriver) commitMessage(changes []Change, defaultMessage string) (string, error) {
+	data := CommitMessageData{
+		File:      gd.File,
+		Branch:    gd.Branch,
+		Counter:   gd.Counter,
+		Changes:   changes,
+		Build:     gd.Build,
+		Timestamp: time.Now().UTC(),
+	}
+	if len(changes) > 0 {
+		data.Version = changes[0].Version
+		data.Previous = changes[0].Previous
+	}
+
+	message := defaultMessage
+	if gd.CommitMessage != "" {
+		var err error
+		if message, err = renderCommitTemplate("commit_message", gd.CommitMessage, data); err != nil {
+			return "", &Error{Kind: KindInvalidConfig, Op: "rendering commit_message", Err: err}
+		}
+	}
+	if gd.SkipCIMarker != "" {
+		message = fmt.Sprintf("%s\n\n%s", message, gd.SkipCIMarker)
+	}
+
+	var trailers []string
+	for _, t := range gd.CommitTrailers {
+		trailer, err := renderCommitTemplate("commit_trailers", t, data)
+		if err != nil {
+			return "", &Error{Kind: KindInvalidConfig, Op: "rendering commit_trailers", Err: err}
+		}
+		trailers = append(trailers, trailer)
+	}
+	trailers = append(trailers, gd.buildTrailers()...)
+	if len(trailers) > 0 {
+		message = fmt.Sprintf("%s\n\n%s", message, strings.Join(trailers, "\n"))
+	}
+	return message, nil
+}
+
+// buildTrailers returns the trailers which link the commit to the build
+func (gd *GitDriver) buildTrailers() []string {
+	var trailers []string
+	if job := gd.build.Job(); job != "" {
+		trailers = append(trailers, fmt.Sprintf("Romver-Build: %s #%s", job, gd.build.Name))
+	}
+	if u := gd.build.URL(); u != "" {
+		trailers = append(trailers, "Romver-Build-URL: "+u)
+	}
+	return trailers
+}
+
+// SetBuild records the build in the commits
+func (gd *GitDriver) SetBuild(build resource.BuildMetadata) {
+	gd.build = build
+	gd.Build = build.Env()
+}
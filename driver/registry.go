@@ -0,0 +1,190 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+// Factory constructs the driver registered with the name
+type Factory struct {
+	// NewConfig returns the pointer to the configuration of the driver.
+	// The keys of source except resource.CommonSourceKeys are decoded into it.
+	NewConfig func() interface{}
+	// New returns the driver from the source and the decoded configuration
+	New func(source resource.Source, config interface{}) (Driver, error)
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[resource.Driver]Factory{}
+)
+
+// Register makes the driver available by the name in source.
+// It panics if the name is registered twice.
+func Register(name resource.Driver, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if name == resource.DriverUnspecified {
+		panic("driver: Register driver name is empty")
+	}
+	if factory.NewConfig == nil || factory.New == nil {
+		panic("driver: Register factory is incomplete for " + string(name))
+	}
+	if _, dup := factories[name]; dup {
+		panic("driver: Register called twice for driver " + string(name))
+	}
+	factories[name] = factory
+}
+
+// Drivers returns the sorted names of the registered drivers
+func Drivers() []resource.Driver {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	var names []resource.Driver
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func lookup(name resource.Driver) (Factory, error) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	if name == resource.DriverUnspecified {
+		return Factory{}, fmt.Errorf("driver is empty")
+	}
+	factory, ok := factories[name]
+	if !ok {
+		return Factory{}, fmt.Errorf("unknown driver: %s", name)
+	}
+	return factory, nil
+}
+
+// NewConfig returns the zero configuration of the registered driver
+func NewConfig(name resource.Driver) (interface{}, error) {
+	factory, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory.NewConfig(), nil
+}
+
+// DecodeConfig decodes the keys of the source for the driver into config.
+// It returns error if the source has keys which are unknown to the driver.
+func DecodeConfig(source resource.Source, config interface{}) error {
+	unknown, err := decodeConfig(source, config)
+	if err != nil {
+		return err
+	}
+	if len(unknown) != 0 {
+		return fmt.Errorf("unknown keys for %s driver: %s", source.Driver, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// decodeConfig decodes the known keys and returns the unknown keys
+func decodeConfig(source resource.Source, config interface{}) ([]string, error) {
+	raw, err := source.Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+	for _, k := range resource.CommonSourceKeys {
+		delete(keys, k)
+	}
+	driverRaw, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	unknown, err := resource.UnknownKeys(driverRaw, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(driverRaw, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration for %s driver: %v", source.Driver, err)
+	}
+	return unknown, nil
+}
+
+// SecretReporter is implemented by the configuration which has secrets
+// other than the values of the keys tagged with `romver:"secret"`, e.g. the encoded credentials
+type SecretReporter interface {
+	Secrets() []string
+}
+
+// Secrets returns the secrets in the source, which the driver declares in its configuration.
+// The configuration may be invalid, so that the secrets can be masked in the errors.
+func Secrets(source resource.Source) []string {
+	factory, err := lookup(source.Driver)
+	if err != nil {
+		return nil
+	}
+	config := factory.NewConfig()
+	// the keys decoded before the invalid one are still masked
+	decodeConfig(source, config)
+	if r, ok := config.(SecretReporter); ok {
+		return r.Secrets()
+	}
+	return resource.SecretValues(config)
+}
+
+// Validate reports all problems of the source and the parameters at once
+// without accessing the backing store. params may be nil.
+func Validate(source resource.Source, params resource.Validatable) error {
+	var problems []string
+	for _, p := range source.Validate() {
+		problems = append(problems, "source: "+p)
+	}
+
+	if source.Driver != resource.DriverUnspecified {
+		factory, err := lookup(source.Driver)
+		if err != nil {
+			problems = append(problems, "source: "+err.Error())
+		} else {
+			config := factory.NewConfig()
+			unknown, err := decodeConfig(source, config)
+			if err != nil {
+				problems = append(problems, "source: "+err.Error())
+			}
+			for _, k := range unknown {
+				problems = append(problems, fmt.Sprintf("source: unknown key for %s driver: %s", source.Driver, k))
+			}
+			if v, ok := config.(resource.Validatable); ok && err == nil {
+				for _, p := range v.Validate() {
+					problems = append(problems, "source: "+p)
+				}
+			}
+		}
+	}
+
+	if params != nil {
+		for _, p := range params.Validate() {
+			problems = append(problems, "params: "+p)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &Error{
+		Kind: KindInvalidConfig,
+		Op:   "invalid configuration",
+		Err:  &resource.ValidationError{Problems: problems},
+	}
+}
@@ -0,0 +1,225 @@
+package driver_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+	. "github.com/cappyzawa/romver-resource/driver"
+)
+
+type fakeConfig struct {
+	Counter string `json:"counter"`
+}
+
+type fakeDriver struct {
+	source resource.Source
+	config *fakeConfig
+}
+
+func (fd *fakeDriver) Bump(ctx context.Context) (string, error) {
+	return fd.config.Counter, nil
+}
+
+func (fd *fakeDriver) Set(ctx context.Context, version string) error {
+	return nil
+}
+
+func (fd *fakeDriver) Check(ctx context.Context, cursor string) ([]string, error) {
+	return []string{fd.source.InitialVersion}, nil
+}
+
+func init() {
+	Register("fake", Factory{
+		NewConfig: func() interface{} {
+			return &fakeConfig{}
+		},
+		New: func(source resource.Source, config interface{}) (Driver, error) {
+			return &fakeDriver{source: source, config: config.(*fakeConfig)}, nil
+		},
+	})
+}
+
+var _ = Describe("Registry", func() {
+	var (
+		payload string
+		d       Driver
+		err     error
+	)
+
+	JustBeforeEach(func() {
+		var source resource.Source
+		Expect(json.Unmarshal([]byte(payload), &source)).To(Succeed())
+		d, err = FromSource(source)
+	})
+
+	Context("when the registered driver is specified", func() {
+		BeforeEach(func() {
+			payload = `{"driver": "fake", "initial_version": "3", "counter": "web"}`
+		})
+		It("decodes its own configuration", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(d).To(BeAssignableToTypeOf(&fakeDriver{}))
+			Expect(d.(*fakeDriver).config.Counter).To(Equal("web"))
+			Expect(d.(*fakeDriver).source.InitialVersion).To(Equal("3"))
+		})
+	})
+
+	Context("when the source has keys of other driver", func() {
+		BeforeEach(func() {
+			payload = `{"driver": "fake", "counter": "web", "uri": "https://example.com", "branch": "version"}`
+		})
+		It("returns all unknown keys", func() {
+			Expect(err).To(MatchError("unknown keys for fake driver: branch, uri"))
+		})
+	})
+
+	Context("when the driver is not registered", func() {
+		BeforeEach(func() {
+			payload = `{"driver": "s3"}`
+		})
+		It("returns error", func() {
+			Expect(err).To(MatchError("unknown driver: s3"))
+		})
+	})
+
+	Describe("Drivers()", func() {
+		BeforeEach(func() {
+			payload = `{"driver": "git"}`
+		})
+		It("returns the registered drivers", func() {
+			Expect(Drivers()).To(ContainElement(resource.DriverGit))
+		})
+	})
+
+	Describe("Secrets()", func() {
+		var secrets []string
+		JustBeforeEach(func() {
+			var source resource.Source
+			Expect(json.Unmarshal([]byte(payload), &source)).To(Succeed())
+			secrets = Secrets(source)
+		})
+		Context("when the source has the credentials", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "git", "uri": "https://example.com/repo.git", "username": "user", "password": "pass", "private_key": "key"}`
+			})
+			It("returns the values of the keys declared by the driver", func() {
+				Expect(secrets).To(ContainElement("pass"))
+				Expect(secrets).To(ContainElement("key"))
+				Expect(secrets).To(ContainElement(base64.StdEncoding.EncodeToString([]byte("user:pass"))))
+				Expect(secrets).NotTo(ContainElement("user"))
+				Expect(secrets).NotTo(ContainElement("https://example.com/repo.git"))
+			})
+		})
+		Context("when the source is invalid", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "git", "password": "pass", "max_retries": "many"}`
+			})
+			It("returns the decoded secrets", func() {
+				Expect(secrets).To(ContainElement("pass"))
+			})
+		})
+		Context("when the driver is not registered", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "s3", "password": "pass"}`
+			})
+			It("returns nothing", func() {
+				Expect(secrets).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Validate()", func() {
+		var (
+			params resource.Validatable
+			verr   error
+		)
+		BeforeEach(func() {
+			payload = `{"driver": "git", "uri": "https://example.com/repo.git", "branch": "version", "file": "version"}`
+			params = nil
+		})
+		JustBeforeEach(func() {
+			var source resource.Source
+			Expect(json.Unmarshal([]byte(payload), &source)).To(Succeed())
+			verr = Validate(source, params)
+		})
+		It("succeeds for the valid source", func() {
+			Expect(verr).NotTo(HaveOccurred())
+		})
+		Context("when the source and the params have problems", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "git", "uri": "https://example.com/repo.git", "initial_version": "v1", "depth": "0", "foo": "bar"}`
+				var p resource.OutParams
+				Expect(json.Unmarshal([]byte(`{"file": "version/number", "bump": true, "bar": 1}`), &p)).To(Succeed())
+				params = p
+			})
+			It("reports all problems at once", func() {
+				Expect(KindOf(verr)).To(Equal(KindInvalidConfig))
+				var ve *resource.ValidationError
+				Expect(errors.As(verr, &ve)).To(BeTrue())
+				Expect(ve.Problems).To(Equal([]string{
+					`source: initial_version must be a non-negative integer: "v1"`,
+					"source: unknown key for git driver: foo",
+					"source: branch is required for git driver",
+					"source: one of file or file_template is required for git driver",
+					`source: depth must be a positive integer: "0"`,
+					"params: unknown key: bar",
+					"params: file and bump are mutually exclusive",
+				}))
+			})
+		})
+		Context("when the commit templates are invalid", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "git", "uri": "https://example.com/repo.git", "branch": "version", "file": "version", "commit_message": "bump {{.Version", "commit_trailers": ["not a trailer"]}`
+			})
+			It("reports the problems", func() {
+				var ve *resource.ValidationError
+				Expect(errors.As(verr, &ve)).To(BeTrue())
+				Expect(ve.Problems).To(HaveLen(2))
+				Expect(ve.Problems[0]).To(HavePrefix("source: commit_message is not a valid template"))
+				Expect(ve.Problems[1]).To(Equal(`source: commit_trailers[0] must be "Key: value": "not a trailer"`))
+			})
+		})
+		Context("when the scheme has problems", func() {
+			BeforeEach(func() {
+				payload = `{"driver": "git", "uri": "https://example.com/repo.git", "branch": "version", "file": "version", "scheme": "calver", "calver_format": "YYYY.MM.MICRO", "initial_version": "0"}`
+			})
+			It("validates initial_version in the scheme", func() {
+				var ve *resource.ValidationError
+				Expect(errors.As(verr, &ve)).To(BeTrue())
+				Expect(ve.Problems).To(Equal([]string{
+					`source: initial_version is not valid: invalid version "0": must match calver_format "YYYY.MM.MICRO"`,
+				}))
+			})
+		})
+		Context("when the counters have problems", func() {
+			BeforeEach(func() {
+				var p resource.OutParams
+				Expect(json.Unmarshal([]byte(`{"counters": [{"counter": "web", "bump": true}, {"counter": "web", "file": "v", "bump": true}, {"path": "db"}]}`), &p)).To(Succeed())
+				params = p
+			})
+			It("reports the problems with the index", func() {
+				var ve *resource.ValidationError
+				Expect(errors.As(verr, &ve)).To(BeTrue())
+				Expect(ve.Problems).To(Equal([]string{
+					"params: counters[1]: file and bump are mutually exclusive",
+					"params: counters[1]: duplicated counter: web",
+					"params: counters[2]: one of file or bump is required",
+				}))
+			})
+		})
+		Context("when neither file nor bump is specified", func() {
+			BeforeEach(func() {
+				params = resource.OutParams{}
+			})
+			It("reports the problem", func() {
+				Expect(verr).To(MatchError(ContainSubstring("params: one of file or bump is required")))
+			})
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRetries is used when max_retries is not specified
+const DefaultMaxRetries = 10
+
+var (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+
+	// retryRand is shared by the drivers which retry concurrently, e.g. the counters of romver serve
+	retryRandMu sync.Mutex
+	retryRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// retry runs fn until it succeeds or the error is not a conflict.
+// Each retry waits for exponential backoff with full jitter.
+func retry(ctx context.Context, maxRetries int, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if KindOf(err) != KindConflict {
+			return err
+		}
+		if attempt >= maxRetries {
+			return &Error{Kind: KindConflict, Op: fmt.Sprintf("giving up after %d retries", maxRetries), Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			// the conflict is not the cause, so that max_retries is not suggested
+			return &Error{Kind: KindUnknown, Op: fmt.Sprintf("retrying after %d conflict(s)", attempt+1), Err: ctx.Err()}
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 16 {
+		if d := retryBaseDelay << uint(attempt); d < retryMaxDelay {
+			delay = d
+		}
+	}
+	retryRandMu.Lock()
+	defer retryRandMu.Unlock()
+	return time.Duration(retryRand.Int63n(int64(delay) + 1))
+}
@@ -0,0 +1,29 @@
+package driver_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cappyzawa/romver-resource/driver"
+)
+
+var _ = Describe("backoff()", func() {
+	It("is safe for the drivers which retry concurrently", func() {
+		defer SetRetryDelay(time.Second)()
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for attempt := 0; attempt < 1000; attempt++ {
+					Expect(ExportBackoff(attempt)).To(BeNumerically("<=", time.Second))
+				}
+			}()
+		}
+		wg.Wait()
+	})
+})
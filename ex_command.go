@@ -2,14 +2,15 @@ package resource
 
 import (
 	"bytes"
-	"fmt"
+	"context"
+	"errors"
 	"os/exec"
 )
 
 // Runnner runs command
 type Runner interface {
-	Run(cmd *exec.Cmd) error
-	CombinedOutput(cmd *exec.Cmd) ([]byte, error)
+	Run(ctx context.Context, cmd *exec.Cmd) error
+	CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error)
 	Error() error
 }
 
@@ -18,19 +19,57 @@ type ExCommand struct {
 	Stderr *bytes.Buffer
 }
 
-func (ec *ExCommand) Run(cmd *exec.Cmd) error {
-	cmd.Stdout = ec.Stdout
-	cmd.Stderr = ec.Stderr
-	return cmd.Run()
+// Run runs the command, whose output replaces the one of the previous command
+// so that Error reports the failure of this command only
+func (ec *ExCommand) Run(ctx context.Context, cmd *exec.Cmd) error {
+	if ec.Stdout != nil {
+		ec.Stdout.Reset()
+		cmd.Stdout = ec.Stdout
+	}
+	if ec.Stderr != nil {
+		ec.Stderr.Reset()
+		cmd.Stderr = ec.Stderr
+	}
+	return run(ctx, cmd)
 }
 
-func (ec *ExCommand) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
-	return cmd.CombinedOutput()
+func (ec *ExCommand) CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := run(ctx, cmd)
+	return b.Bytes(), err
 }
 
 func (ec *ExCommand) Error() error {
 	if ec.Stderr != nil {
-		return fmt.Errorf(ec.Stderr.String())
+		return errors.New(ec.Stderr.String())
 	}
 	return nil
 }
+
+// run kills the command when ctx is done
+func run(ctx context.Context, cmd *exec.Cmd) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// the children such as git-remote-https hold the output, so that they are killed together
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+var _ = Describe("ExCommand", func() {
+	var runner *resource.ExCommand
+
+	BeforeEach(func() {
+		runner = &resource.ExCommand{
+			Stdout: new(bytes.Buffer),
+			Stderr: new(bytes.Buffer),
+		}
+	})
+
+	It("returns the output", func() {
+		out, err := runner.CombinedOutput(context.Background(), exec.Command("echo", "romver"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal("romver\n"))
+	})
+
+	It("reports the error output of the last command only", func() {
+		Expect(runner.Run(context.Background(), exec.Command("sh", "-c", "echo 'Permission denied' >&2; exit 1"))).To(HaveOccurred())
+		Expect(runner.Run(context.Background(), exec.Command("sh", "-c", "echo 'Repository not found' >&2; exit 1"))).To(HaveOccurred())
+		Expect(runner.Error()).To(MatchError("Repository not found\n"))
+	})
+
+	Context("when the context is done", func() {
+		It("kills the command", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := runner.Run(ctx, exec.Command("sleep", "10"))
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+		It("kills the children which hold the output", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			_, err := runner.CombinedOutput(ctx, exec.Command("sh", "-c", "sleep 3; echo done"))
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		})
+	})
+})
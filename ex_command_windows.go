@@ -0,0 +1,11 @@
+package resource
+
+import "os/exec"
+
+// setProcessGroup does nothing, since the process group is not available
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the command only
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
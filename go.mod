@@ -5,4 +5,5 @@ go 1.12
 require (
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.18.1
+	gopkg.in/yaml.v3 v3.0.1
 )
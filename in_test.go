@@ -3,6 +3,7 @@ package resource_test
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -17,7 +18,9 @@ import (
 )
 
 var _ = Describe("In", func() {
-	var desDir string
+	var desDir, repoDir, uri string
+	// seedFile has seedContent in the remote, which defaults to the requested version
+	var seedFile, seedContent string
 	var req struct {
 		Source  resource.Source
 		Version resource.Version
@@ -28,11 +31,16 @@ var _ = Describe("In", func() {
 		Version  resource.Version
 		Metadata resource.Metadata
 	}
+	var errBuf *bytes.Buffer
 
 	BeforeEach(func() {
 		var err error
 		desDir, err = ioutil.TempDir("", "romver-resource-in-dir")
 		Expect(err).NotTo(HaveOccurred())
+		repoDir, err = ioutil.TempDir("", "romver-resource-in-repo")
+		Expect(err).NotTo(HaveOccurred())
+		uri = filepath.Join(repoDir, "remote.git")
+		seedFile, seedContent = "version", ""
 
 		req.Source = resource.Source{}
 		req.Params = resource.InParams{}
@@ -42,9 +50,16 @@ var _ = Describe("In", func() {
 	})
 	AfterEach(func() {
 		Expect(os.RemoveAll(desDir)).To(Succeed())
+		Expect(os.RemoveAll(repoDir)).To(Succeed())
 	})
 
 	JustBeforeEach(func() {
+		// the requested version must be stored in the history
+		if seedContent == "" {
+			seedContent = req.Version.Number
+		}
+		newBareRepo(repoDir, "version", seedFile, seedContent)
+
 		cmd := exec.Command(bins.In, desDir)
 
 		payload, err := json.Marshal(req)
@@ -54,7 +69,9 @@ var _ = Describe("In", func() {
 
 		cmd.Stdin = bytes.NewBuffer(payload)
 		cmd.Stdout = inBuf
-		cmd.Stderr = GinkgoWriter
+		errBuf = new(bytes.Buffer)
+		cmd.Stderr = io.MultiWriter(errBuf, GinkgoWriter)
+		cmd.Env = isolatedEnv(repoDir)
 
 		err = cmd.Run()
 		Expect(err).ToNot(HaveOccurred())
@@ -65,17 +82,13 @@ var _ = Describe("In", func() {
 
 	Context("no bump", func() {
 		BeforeEach(func() {
-			req.Source = resource.Source{
-				Driver: "git",
-
-				InitialVersion: "0",
-
-				URI:      githubURI,
-				Branch:   githubBranch,
-				Username: githubUsername,
-				Password: githubPassword,
-				File:     "version",
-			}
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             uri,
+				"branch":          "version",
+				"file":            "version",
+			})
 
 			req.Params = resource.InParams{
 				Bump: false,
@@ -101,17 +114,13 @@ var _ = Describe("In", func() {
 
 	Context("bump", func() {
 		BeforeEach(func() {
-			req.Source = resource.Source{
-				Driver: "git",
-
-				InitialVersion: "0",
-
-				URI:      githubURI,
-				Branch:   githubBranch,
-				Username: githubUsername,
-				Password: githubPassword,
-				File:     "version",
-			}
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             uri,
+				"branch":          "version",
+				"file":            "version",
+			})
 
 			req.Params = resource.InParams{
 				Bump: true,
@@ -136,5 +145,56 @@ var _ = Describe("In", func() {
 			expect := strconv.Itoa(expectInt)
 			Expect(number).To(Equal(expect))
 		})
+
+		It("reports the bumped version as a preview", func() {
+			Expect(res.Metadata).To(ContainElement(resource.MetadataField{Name: "bumped_from", Value: "111"}))
+			Expect(res.Metadata).To(ContainElement(resource.MetadataField{Name: "bumped_to", Value: "112"}))
+			Expect(res.Metadata).To(ContainElement(resource.MetadataField{Name: "reserved", Value: "false"}))
+		})
+	})
+
+	Context("branch", func() {
+		BeforeEach(func() {
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             uri,
+				"branch":          "version",
+				"file_template":   "versions/%branch%",
+			})
+
+			req.Params = resource.InParams{
+				Branch: "feat",
+			}
+
+			// check reports initial_version without the branch
+			req.Version = resource.Version{
+				Number: "0",
+			}
+			seedFile, seedContent = "versions/feat", "2"
+		})
+
+		It("provides the version of the branch", func() {
+			Expect(res.Version.Number).To(Equal("2"))
+			b, err := ioutil.ReadFile(filepath.Join(desDir, "version"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(string(b))).To(Equal("2"))
+			Expect(res.Metadata).To(ContainElement(resource.MetadataField{Name: "branch", Value: "feat"}))
+		})
+
+		It("warns that the version may be cached for the requested version", func() {
+			Expect(errBuf.String()).To(ContainSubstring("warning: version 2 of branch feat is not the requested version 0"))
+		})
+
+		Context("when the requested version is the one of the branch", func() {
+			BeforeEach(func() {
+				// e.g. the implicit get after put with the branch
+				req.Version = resource.Version{Number: "2"}
+			})
+			It("does not warn", func() {
+				Expect(res.Version.Number).To(Equal("2"))
+				Expect(errBuf.String()).NotTo(ContainSubstring("warning"))
+			})
+		})
 	})
 })
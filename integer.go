@@ -0,0 +1,39 @@
+package resource
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// IntegerScheme is the scheme of the plain integer versions.
+// The versions are arbitrary-precision, so that they never overflow.
+type IntegerScheme struct{}
+
+// Parse returns the *big.Int of the version
+func (IntegerScheme) Parse(version string) (Value, error) {
+	n, ok := new(big.Int).SetString(version, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid version %q: must be an integer", version)
+	}
+	return n, nil
+}
+
+// Format returns the decimal of the value
+func (IntegerScheme) Format(v Value) string {
+	return v.(*big.Int).String()
+}
+
+// Next returns the value incremented by 1
+func (IntegerScheme) Next(v Value) (Value, error) {
+	return new(big.Int).Add(v.(*big.Int), big.NewInt(1)), nil
+}
+
+// Compare compares the values numerically
+func (IntegerScheme) Compare(a, b Value) int {
+	return a.(*big.Int).Cmp(b.(*big.Int))
+}
+
+// Initial returns 0
+func (IntegerScheme) Initial() Value {
+	return new(big.Int)
+}
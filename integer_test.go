@@ -0,0 +1,34 @@
+package resource_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+var _ = Describe("IntegerScheme", func() {
+	scheme := resource.IntegerScheme{}
+
+	Describe("NextVersion()", func() {
+		It("increments the version by n", func() {
+			Expect(resource.NextVersion(scheme, "4", 3)).To(Equal("7"))
+		})
+		It("does not overflow", func() {
+			Expect(resource.NextVersion(scheme, "9223372036854775807", 1)).To(Equal("9223372036854775808"))
+		})
+		It("returns error for the version which is not a number", func() {
+			_, err := resource.NextVersion(scheme, "v4", 1)
+			Expect(err).To(MatchError(`invalid version "v4": must be an integer`))
+		})
+	})
+
+	Describe("CompareVersions()", func() {
+		It("compares the versions numerically", func() {
+			Expect(resource.CompareVersions(scheme, "9", "10")).To(Equal(-1))
+			Expect(resource.CompareVersions(scheme, "10", "10")).To(Equal(0))
+			Expect(resource.CompareVersions(scheme, "10", "9")).To(Equal(1))
+			Expect(resource.CompareVersions(scheme, "100000000000000000000", "99999999999999999999")).To(Equal(1))
+		})
+	})
+})
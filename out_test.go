@@ -62,17 +62,15 @@ var _ = Describe("Out", func() {
 
 	Context("bump based on git file", func() {
 		BeforeEach(func() {
-			req.Source = resource.Source{
-				Driver: "git",
-
-				InitialVersion: "0",
-
-				URI:      githubURI,
-				Branch:   githubBranch,
-				Username: githubUsername,
-				Password: githubPassword,
-				File:     "version",
-			}
+			req.Source = newSource(map[string]interface{}{
+				"driver":          "git",
+				"initial_version": "0",
+				"uri":             githubURI,
+				"branch":          githubBranch,
+				"username":        githubUsername,
+				"password":        githubPassword,
+				"file":            "version",
+			})
 			req.Params = resource.OutParams{
 				Bump: true,
 			}
@@ -0,0 +1,90 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// VersionOutput is the version provided to the build by get step.
+// It is written to version.json and exposed to output_templates.
+type VersionOutput struct {
+	Number  string `json:"number"`
+	Version string `json:"version"`
+	// Commit is the commit which stored the version, empty if it is unknown
+	Commit    string    `json:"commit,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Branch    string    `json:"branch,omitempty"`
+}
+
+// reservedOutputFiles are written by get step regardless of output_templates
+var reservedOutputFiles = map[string]bool{
+	"number":       true,
+	"version":      true,
+	"version.json": true,
+	"version.env":  true,
+}
+
+// Env returns the lines of version.env, which can be sourced by shells
+func (o VersionOutput) Env() string {
+	var buf bytes.Buffer
+	for _, kv := range [][2]string{
+		{"ROMVER_NUMBER", o.Number},
+		{"ROMVER_VERSION", o.Version},
+		{"ROMVER_COMMIT", o.Commit},
+		{"ROMVER_TIMESTAMP", o.Timestamp.Format(time.RFC3339)},
+		{"ROMVER_BRANCH", o.Branch},
+	} {
+		fmt.Fprintf(&buf, "%s=%s\n", kv[0], shellQuote(kv[1]))
+	}
+	return buf.String()
+}
+
+// Render returns the content of the output template
+func (o VersionOutput) Render(name, text string) (string, error) {
+	t, err := parseOutputTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, o); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseOutputTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+// shellQuote quotes the value with single quotes
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// outputTemplateProblems reports the file names and the templates which can not be rendered
+func outputTemplateProblems(templates map[string]string) []string {
+	var problems []string
+	for _, name := range sortedKeys(templates) {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || reservedOutputFiles[name] {
+			problems = append(problems, fmt.Sprintf("output_templates: invalid file name: %q", name))
+			continue
+		}
+		if _, err := (VersionOutput{}).Render(name, templates[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("output_templates.%s is not a valid template: %v", name, err))
+		}
+	}
+	return problems
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
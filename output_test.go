@@ -0,0 +1,47 @@
+package resource_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+var _ = Describe("VersionOutput", func() {
+	var output resource.VersionOutput
+
+	BeforeEach(func() {
+		output = resource.VersionOutput{
+			Number:    "12",
+			Version:   "12",
+			Commit:    "abc",
+			Timestamp: time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC),
+			Branch:    "it's",
+		}
+	})
+
+	It("quotes the values of version.env", func() {
+		Expect(output.Env()).To(Equal("ROMVER_NUMBER='12'\n" +
+			"ROMVER_VERSION='12'\n" +
+			"ROMVER_COMMIT='abc'\n" +
+			"ROMVER_TIMESTAMP='2026-10-19T09:00:00Z'\n" +
+			"ROMVER_BRANCH='it'\\''s'\n"))
+	})
+
+	It("renders the output template", func() {
+		Expect(output.Render("chart_version", "1.0.{{.Number}}")).To(Equal("1.0.12"))
+	})
+
+	It("reports the invalid output templates", func() {
+		var p resource.InParams
+		Expect(json.Unmarshal([]byte(`{"output_templates": {"tag": "v{{.Number}}", "../tag": "v", "version.json": "{}", "x": "{{.Tag}}"}}`), &p)).To(Succeed())
+		problems := p.Validate()
+		Expect(problems).To(HaveLen(3))
+		Expect(problems[0]).To(Equal(`output_templates: invalid file name: "../tag"`))
+		Expect(problems[1]).To(Equal(`output_templates: invalid file name: "version.json"`))
+		Expect(problems[2]).To(HavePrefix("output_templates.x is not a valid template"))
+	})
+})
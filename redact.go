@@ -0,0 +1,132 @@
+package resource
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces the secrets in the output
+const Redacted = "((redacted))"
+
+// secrets shorter than this are not redacted line by line
+// so that common lines such as "-----END ... KEY-----" are not all masked
+const minSecretLineLength = 16
+
+var credentialURLRegexp = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s@]+@`)
+
+// Redactor masks the secrets and credentials in the URLs
+type Redactor struct {
+	secrets []string
+}
+
+// NewRedactor returns redactor which masks the given secrets
+func NewRedactor(secrets ...string) *Redactor {
+	var rs []string
+	for _, s := range secrets {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		rs = append(rs, s)
+		// key material may be printed partially
+		if lines := strings.Split(s, "\n"); len(lines) > 1 {
+			for _, l := range lines {
+				if l = strings.TrimSpace(l); len(l) >= minSecretLineLength {
+					rs = append(rs, l)
+				}
+			}
+		}
+	}
+	// longer secrets first so that a secret containing another is masked entirely
+	sort.Slice(rs, func(i, j int) bool {
+		return len(rs[i]) > len(rs[j])
+	})
+	return &Redactor{secrets: rs}
+}
+
+// Redact returns s which the secrets are masked
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, secret := range r.secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+	}
+	return credentialURLRegexp.ReplaceAllString(s, "${1}"+Redacted+"@")
+}
+
+// Writer returns writer which masks the secrets before writing to w
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return &redactWriter{redactor: r, w: w}
+}
+
+// Runner returns runner which masks the secrets in the output of the commands
+func (r *Redactor) Runner(runner Runner) Runner {
+	if r == nil {
+		return runner
+	}
+	return &redactRunner{redactor: r, runner: runner}
+}
+
+type redactWriter struct {
+	redactor *Redactor
+	w        io.Writer
+}
+
+func (rw *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, rw.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+type redactRunner struct {
+	redactor *Redactor
+	runner   Runner
+}
+
+func (rr *redactRunner) Run(ctx context.Context, cmd *exec.Cmd) error {
+	return rr.runner.Run(ctx, cmd)
+}
+
+func (rr *redactRunner) CombinedOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	out, err := rr.runner.CombinedOutput(ctx, cmd)
+	return []byte(rr.redactor.Redact(string(out))), err
+}
+
+func (rr *redactRunner) Error() error {
+	err := rr.runner.Error()
+	if err == nil {
+		return nil
+	}
+	return &redactedError{msg: rr.redactor.Redact(err.Error())}
+}
+
+type redactedError struct {
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// SecretValues returns the values of the string fields tagged with `romver:"secret"`
+func SecretValues(v interface{}) []string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	var secrets []string
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		if HasTagOption(f, "secret") && f.Type.Kind() == reflect.String {
+			secrets = append(secrets, rv.Field(i).String())
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,180 @@
+// Package romver provides the version counter of romver-resource for Go programs,
+// so that they can bump and read the versions without running the resource binaries.
+//
+//	counter, err := romver.Open(romver.Config{
+//		Driver: "git",
+//		Options: map[string]interface{}{
+//			"uri":         "git@github.com:cappyzawa/romver.git",
+//			"branch":      "version",
+//			"file":        "version",
+//			"private_key": key,
+//		},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	version, err := counter.Bump(ctx, 1)
+//
+// The errors returned by the counter are *driver.Error, whose kind is reported by driver.KindOf.
+package romver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+)
+
+// Counter stores the version number
+type Counter interface {
+	// Get returns the current version, or the initial version if no version is stored yet
+	Get(ctx context.Context) (string, error)
+	// Bump increments the version by n and returns the new version
+	Bump(ctx context.Context, n int) (string, error)
+	// Set stores the version
+	Set(ctx context.Context, version string) error
+	// Init stores the initial version if no version is stored yet, and reports whether it is stored
+	Init(ctx context.Context) (bool, error)
+	// History returns the versions stored in the past from newest to oldest, limit <= 0 means no limit
+	History(ctx context.Context, limit int) ([]Record, error)
+}
+
+// Record represents the version stored in the past
+type Record = driver.Record
+
+// Config is the configuration of the counter
+type Config struct {
+	// Driver is the name of the registered driver, e.g. "git"
+	Driver string
+	// InitialVersion is the version used when no version is stored yet, defaults to the initial version of the scheme
+	InitialVersion string
+	// Timeout bounds each operation, zero means no timeout
+	Timeout time.Duration
+	// Options are the keys of source for the driver, e.g. "uri", "branch" and "file" for git
+	Options map[string]interface{}
+	// WorkDir is the directory of the files of the driver such as the clone, defaults to the one of the driver
+	WorkDir string
+}
+
+// Source returns the source of the resource which is equivalent to the config
+func (c Config) Source() (resource.Source, error) {
+	values := map[string]interface{}{}
+	for k, v := range c.Options {
+		values[k] = v
+	}
+	values["driver"] = c.Driver
+	if c.InitialVersion != "" {
+		values["initial_version"] = c.InitialVersion
+	}
+	if c.Timeout > 0 {
+		values["timeout"] = c.Timeout.String()
+	}
+
+	return resource.NewSource(values)
+}
+
+// Open validates the config and returns the counter
+func Open(config Config) (Counter, error) {
+	source, err := config.Source()
+	if err != nil {
+		return nil, err
+	}
+	return FromSourceInDir(source, config.WorkDir)
+}
+
+// FromSource validates the source of the resource and returns the counter
+func FromSource(source resource.Source) (Counter, error) {
+	return FromSourceInDir(source, "")
+}
+
+// FromSourceInDir is FromSource whose driver writes its files such as the clone under dir.
+// The counters in the same process must be given different directories.
+func FromSourceInDir(source resource.Source, dir string) (Counter, error) {
+	if err := driver.Validate(source, nil); err != nil {
+		return nil, err
+	}
+	d, err := driver.FromSource(source)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		driver.SetWorkDir(d, dir)
+	}
+	scheme, err := source.VersionScheme()
+	if err != nil {
+		return nil, err
+	}
+	return New(d, scheme), nil
+}
+
+// New returns the counter backed by the driver, whose versions are in the scheme.
+// If scheme is nil, the versions are validated by the driver only.
+// The operations which the driver does not support return the error.
+func New(d driver.Driver, scheme resource.Scheme) Counter {
+	return &counter{driver: d, scheme: scheme}
+}
+
+type counter struct {
+	driver driver.Driver
+	scheme resource.Scheme
+}
+
+func (c *counter) Get(ctx context.Context) (string, error) {
+	if g, ok := c.driver.(driver.Getter); ok {
+		return g.Get(ctx)
+	}
+
+	versions, err := c.driver.Check(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", &driver.Error{Kind: driver.KindNotFound, Op: "getting version", Err: errors.New("no version is found")}
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (c *counter) Bump(ctx context.Context, n int) (string, error) {
+	if n < 1 {
+		return "", &driver.Error{Kind: driver.KindInvalidConfig, Op: "bumping version", Err: fmt.Errorf("n must be positive: %d", n)}
+	}
+	if s, ok := c.driver.(driver.Stepper); ok {
+		return s.BumpBy(ctx, n)
+	}
+	if n != 1 {
+		return "", unsupported("bumping by more than one")
+	}
+	return c.driver.Bump(ctx)
+}
+
+func (c *counter) Set(ctx context.Context, version string) error {
+	if c.scheme != nil {
+		if err := resource.ValidateVersion(c.scheme, version); err != nil {
+			return &driver.Error{Kind: driver.KindInvalidVersion, Op: "parsing version", Err: err}
+		}
+	}
+	return c.driver.Set(ctx, version)
+}
+
+func (c *counter) Init(ctx context.Context) (bool, error) {
+	i, ok := c.driver.(driver.Initializer)
+	if !ok {
+		return false, unsupported("init")
+	}
+	return i.Init(ctx)
+}
+
+func (c *counter) History(ctx context.Context, limit int) ([]Record, error) {
+	h, ok := c.driver.(driver.Historian)
+	if !ok {
+		return nil, unsupported("history")
+	}
+	return h.History(ctx, limit)
+}
+
+func unsupported(op string) error {
+	return &driver.Error{Kind: driver.KindInvalidConfig, Op: op, Err: errors.New("not supported by the driver")}
+}
@@ -0,0 +1,153 @@
+package romver_test
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+	"github.com/cappyzawa/romver-resource/romver"
+)
+
+// memoryDriver stores the version in memory and supports only the methods of driver.Driver
+type memoryDriver struct {
+	version string
+}
+
+func (md *memoryDriver) Bump(ctx context.Context) (string, error) {
+	v, _ := strconv.Atoi(md.version)
+	md.version = strconv.Itoa(v + 1)
+	return md.version, nil
+}
+
+func (md *memoryDriver) Set(ctx context.Context, version string) error {
+	md.version = version
+	return nil
+}
+
+func (md *memoryDriver) Check(ctx context.Context, cursor string) ([]string, error) {
+	return []string{md.version}, nil
+}
+
+// historyDriver also supports the optional interfaces
+type historyDriver struct {
+	memoryDriver
+	records []driver.Record
+}
+
+func (hd *historyDriver) BumpBy(ctx context.Context, n int) (string, error) {
+	v, _ := strconv.Atoi(hd.version)
+	hd.version = strconv.Itoa(v + n)
+	return hd.version, nil
+}
+
+func (hd *historyDriver) History(ctx context.Context, limit int) ([]driver.Record, error) {
+	return hd.records, nil
+}
+
+var _ = Describe("Counter", func() {
+	var (
+		ctx     context.Context
+		counter romver.Counter
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		counter = romver.New(&memoryDriver{version: "4"}, resource.IntegerScheme{})
+	})
+
+	It("gets the version with Check when the driver is not a Getter", func() {
+		Expect(counter.Get(ctx)).To(Equal("4"))
+	})
+
+	It("bumps the version by one", func() {
+		Expect(counter.Bump(ctx, 1)).To(Equal("5"))
+		Expect(counter.Get(ctx)).To(Equal("5"))
+	})
+
+	It("rejects n less than one", func() {
+		_, err := counter.Bump(ctx, 0)
+		Expect(driver.KindOf(err)).To(Equal(driver.KindInvalidConfig))
+	})
+
+	It("rejects bumping by more than one when the driver is not a Stepper", func() {
+		_, err := counter.Bump(ctx, 2)
+		Expect(err).To(MatchError("bumping by more than one: not supported by the driver"))
+	})
+
+	It("rejects the version which is not a number", func() {
+		err := counter.Set(ctx, "v5")
+		Expect(driver.KindOf(err)).To(Equal(driver.KindInvalidVersion))
+		Expect(counter.Get(ctx)).To(Equal("4"))
+	})
+
+	It("validates the version in the scheme of the counter", func() {
+		scheme, err := resource.NewCalVer("YYYY.MM.MICRO", time.Now)
+		Expect(err).NotTo(HaveOccurred())
+		counter = romver.New(&memoryDriver{version: "2026.10.0"}, scheme)
+		Expect(counter.Set(ctx, "2026.10.1")).To(Succeed())
+		Expect(driver.KindOf(counter.Set(ctx, "5"))).To(Equal(driver.KindInvalidVersion))
+		Expect(counter.Get(ctx)).To(Equal("2026.10.1"))
+	})
+
+	It("leaves the validation to the driver without the scheme", func() {
+		counter = romver.New(&memoryDriver{version: "4"}, nil)
+		Expect(counter.Set(ctx, "v5")).To(Succeed())
+	})
+
+	It("returns error for the operations which the driver does not support", func() {
+		_, err := counter.History(ctx, 0)
+		Expect(err).To(MatchError("history: not supported by the driver"))
+		_, err = counter.Init(ctx)
+		Expect(err).To(MatchError("init: not supported by the driver"))
+	})
+
+	Context("when the driver supports the optional interfaces", func() {
+		var now time.Time
+		BeforeEach(func() {
+			now = time.Now()
+			counter = romver.New(&historyDriver{
+				memoryDriver: memoryDriver{version: "4"},
+				records:      []driver.Record{{Version: "4", Commit: "abc", Author: "bot", Time: now}},
+			}, resource.IntegerScheme{})
+		})
+		It("bumps the version by n atomically", func() {
+			Expect(counter.Bump(ctx, 3)).To(Equal("7"))
+		})
+		It("returns the history", func() {
+			Expect(counter.History(ctx, 0)).To(Equal([]romver.Record{{Version: "4", Commit: "abc", Author: "bot", Time: now}}))
+		})
+	})
+
+	Describe("Open()", func() {
+		It("reports the problems of the config", func() {
+			_, err := romver.Open(romver.Config{
+				Driver:  "git",
+				Options: map[string]interface{}{"uri": "https://example.com/repo.git", "depth": "0"},
+			})
+			Expect(driver.KindOf(err)).To(Equal(driver.KindInvalidConfig))
+			Expect(err).To(MatchError(ContainSubstring("source: branch is required for git driver")))
+		})
+
+		It("converts the config to the source", func() {
+			source, err := romver.Config{
+				Driver:         "git",
+				InitialVersion: "3",
+				Timeout:        time.Minute,
+				Options:        map[string]interface{}{"uri": "https://example.com/repo.git", "max_retries": 2},
+			}.Source()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(source.Driver)).To(Equal("git"))
+			Expect(source.InitialVersion).To(Equal("3"))
+			Expect(source.Timeout).To(Equal("1m0s"))
+			var config driver.GitConfig
+			Expect(driver.DecodeConfig(source, &config)).To(Succeed())
+			Expect(config.URI).To(Equal("https://example.com/repo.git"))
+			Expect(config.MaxRetries).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,141 @@
+// Package schema generates JSON Schema of the resource configuration from the Go types,
+// so that it does not drift from what the commands decode.
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+)
+
+// Draft is the JSON Schema version of the generated schema
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Schema represents JSON Schema
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Generate returns the schema which defines source, in_params and out_params.
+// source is one of the configurations for the registered drivers.
+func Generate() (*Schema, error) {
+	source := &Schema{}
+	for _, name := range driver.Drivers() {
+		config, err := driver.NewConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		s := Source(name, config)
+		s.Title = string(name) + " driver"
+		source.OneOf = append(source.OneOf, s)
+	}
+
+	return &Schema{
+		Schema: Draft,
+		ID:     "https://github.com/cappyzawa/romver-resource/schema.json",
+		Title:  "romver-resource",
+		Definitions: map[string]*Schema{
+			"source":     source,
+			"in_params":  Of(resource.InParams{}),
+			"out_params": Of(resource.OutParams{}),
+		},
+	}, nil
+}
+
+// Source returns the schema of the source for the driver,
+// which consists of the common keys and the keys of config.
+func Source(name resource.Driver, config interface{}) *Schema {
+	s := Of(resource.Source{})
+	s.Properties["driver"] = &Schema{Const: string(name)}
+
+	c := Of(config)
+	for k, p := range c.Properties {
+		s.Properties[k] = p
+	}
+	s.Required = append(s.Required, c.Required...)
+	sort.Strings(s.Required)
+	return s
+}
+
+// Of returns the schema of the value from its type
+func Of(v interface{}) *Schema {
+	return of(reflect.TypeOf(v))
+}
+
+func of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: of(t.Elem())}
+	case reflect.Struct:
+		// unknown keys are reported as problems on validation
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+		addFields(s, t)
+		sort.Strings(s.Required)
+		return s
+	default:
+		// e.g. interface{} accepts any value
+		return &Schema{}
+	}
+}
+
+func addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			addFields(s, ft)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		p := of(f.Type)
+		if enum := resource.TagEnum(f); enum != nil {
+			p.Enum = enum
+		}
+		s.Properties[name] = p
+		if resource.HasTagOption(f, "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package schema_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+	. "github.com/cappyzawa/romver-resource/schema"
+)
+
+var _ = Describe("Schema", func() {
+	var sc *Schema
+
+	BeforeEach(func() {
+		var err error
+		sc, err = Generate()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("defines source for each driver", func() {
+		Expect(sc.Definitions["source"].OneOf).To(HaveLen(len(driver.Drivers())))
+	})
+
+	It("defines source for git driver from its configuration", func() {
+		git := sc.Definitions["source"].OneOf[0]
+		Expect(git.Properties["driver"].Const).To(Equal("git"))
+		Expect(git.Required).To(Equal([]string{"branch", "driver", "uri"}))
+		Expect(git.AdditionalProperties).To(Equal(false))
+		for _, k := range append(resource.CommonSourceKeys, resource.JSONKeys(driver.GitConfig{})...) {
+			Expect(git.Properties).To(HaveKey(k))
+		}
+	})
+
+	It("defines params from their types", func() {
+		Expect(sc.Definitions["out_params"].Properties).To(HaveKey("file"))
+		Expect(sc.Definitions["out_params"].Properties["bump"].Type).To(Equal("boolean"))
+		Expect(sc.Definitions["in_params"].Properties).To(HaveKey("bump"))
+	})
+})
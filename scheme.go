@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SchemeInteger is the scheme of the plain integer versions, which is the default
+	SchemeInteger = "integer"
+	// SchemeCalVer is the scheme of the calendar-based versions such as 2026.10.3
+	SchemeCalVer = "calver"
+)
+
+// DefaultCalVerFormat is calver_format used when it is not specified
+const DefaultCalVerFormat = "YYYY.MM.MICRO"
+
+// Value is the parsed version, whose type depends on the scheme.
+// The value must be passed to the scheme which parsed it.
+type Value interface{}
+
+// Scheme parses, formats, increments and compares the versions.
+// The drivers and the commands handle the versions only through the scheme,
+// so that a new scheme does not require changes of them.
+type Scheme interface {
+	// Parse returns the value of the version, or the error if it is not valid in the scheme
+	Parse(version string) (Value, error)
+	// Format returns the version of the value
+	Format(v Value) string
+	// Next returns the value after the value, or the error if the scheme has no value after it
+	Next(v Value) (Value, error)
+	// Compare returns -1, 0 or 1 when a is less than, equal to or greater than b
+	Compare(a, b Value) int
+	// Initial returns the value used when neither a version nor initial_version is given
+	Initial() Value
+}
+
+// NewScheme returns the scheme of the name, format is used by calver
+func NewScheme(name, format string) (Scheme, error) {
+	switch name {
+	case "", SchemeInteger:
+		return IntegerScheme{}, nil
+	case SchemeCalVer:
+		return NewCalVer(format, time.Now)
+	default:
+		return nil, fmt.Errorf("unknown scheme: %q", name)
+	}
+}
+
+// VersionScheme returns the scheme of the source
+func (s Source) VersionScheme() (Scheme, error) {
+	return NewScheme(s.Scheme, s.CalVerFormat)
+}
+
+// InitialVersionOrDefault returns initial_version, or the initial version of the scheme if it is not specified
+func (s Source) InitialVersionOrDefault() string {
+	if s.InitialVersion != "" {
+		return s.InitialVersion
+	}
+	scheme, err := s.VersionScheme()
+	if err != nil {
+		scheme = IntegerScheme{}
+	}
+	return scheme.Format(scheme.Initial())
+}
+
+// ValidateVersion returns the error if the version is not valid in the scheme
+func ValidateVersion(s Scheme, version string) error {
+	_, err := s.Parse(version)
+	return err
+}
+
+// NextVersion returns the version bumped n times in the scheme
+func NextVersion(s Scheme, version string, n int) (string, error) {
+	v, err := s.Parse(version)
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < n; i++ {
+		if v, err = s.Next(v); err != nil {
+			return "", err
+		}
+	}
+	return s.Format(v), nil
+}
+
+// CompareVersions returns -1, 0 or 1 when a is less than, equal to or greater than b in the scheme
+func CompareVersions(s Scheme, a, b string) (int, error) {
+	x, err := s.Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := s.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return s.Compare(x, y), nil
+}
@@ -0,0 +1,114 @@
+package resource_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	resource "github.com/cappyzawa/romver-resource"
+)
+
+var _ = Describe("Scheme", func() {
+	now := func() time.Time {
+		return time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	}
+
+	Context("integer", func() {
+		var scheme resource.Scheme
+
+		BeforeEach(func() {
+			var err error
+			scheme, err = resource.NewScheme("", "")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("increments the number", func() {
+			Expect(resource.NextVersion(scheme, "9", 1)).To(Equal("10"))
+			Expect(resource.CompareVersions(scheme, "9", "10")).To(Equal(-1))
+			Expect(scheme.Format(scheme.Initial())).To(Equal("0"))
+		})
+	})
+
+	Context("calver with MICRO", func() {
+		var scheme *resource.CalVer
+
+		BeforeEach(func() {
+			var err error
+			scheme, err = resource.NewCalVer("YYYY.MM.MICRO", now)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("resets MICRO when the period changes", func() {
+			next, err := scheme.Next(scheme.Initial())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(scheme.Format(next)).To(Equal("2026.10.0"))
+			Expect(resource.NextVersion(scheme, "2026.9.7", 1)).To(Equal("2026.10.0"))
+		})
+
+		It("increments MICRO within the period", func() {
+			Expect(resource.NextVersion(scheme, "2026.10.2", 1)).To(Equal("2026.10.3"))
+			// the clock may be behind the version
+			Expect(resource.NextVersion(scheme, "2026.11.0", 1)).To(Equal("2026.11.1"))
+		})
+
+		It("compares the periods and MICRO", func() {
+			Expect(resource.CompareVersions(scheme, "2026.10.10", "2026.10.9")).To(Equal(1))
+			Expect(resource.CompareVersions(scheme, "2026.9.10", "2026.10.0")).To(Equal(-1))
+			Expect(resource.CompareVersions(scheme, "2026.10.3", "2026.10.3")).To(Equal(0))
+		})
+
+		It("rejects the versions which do not match the format", func() {
+			Expect(resource.ValidateVersion(scheme, "7")).To(HaveOccurred())
+			_, err := resource.NextVersion(scheme, "2026-10-3", 1)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects the months which do not exist", func() {
+			Expect(resource.ValidateVersion(scheme, "2026.13.0")).To(MatchError(ContainSubstring("month must be between 1 and 12")))
+			Expect(resource.ValidateVersion(scheme, "2026.0.1")).To(HaveOccurred())
+			Expect(resource.ValidateVersion(scheme, "0000.0.0")).To(Succeed())
+		})
+	})
+
+	Context("calver without MICRO", func() {
+		var scheme *resource.CalVer
+
+		BeforeEach(func() {
+			var err error
+			scheme, err = resource.NewCalVer("YYYY0M0D", now)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("moves the version to the current period", func() {
+			Expect(resource.NextVersion(scheme, "20261017", 1)).To(Equal("20261018"))
+			Expect(resource.NextVersion(scheme, "00000000", 1)).To(Equal("20261018"))
+		})
+
+		It("does not bump twice in the period", func() {
+			_, err := resource.NextVersion(scheme, "20261018", 1)
+			Expect(err).To(MatchError(ContainSubstring("allows a single version per period")))
+			_, err = resource.NextVersion(scheme, "20261017", 2)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects the days which do not exist", func() {
+			Expect(resource.ValidateVersion(scheme, "20261031")).To(Succeed())
+			Expect(resource.ValidateVersion(scheme, "20261032")).To(MatchError(ContainSubstring("day must be between 1 and 31")))
+			Expect(resource.ValidateVersion(scheme, "20261399")).To(HaveOccurred())
+			Expect(resource.ValidateVersion(scheme, "20260229")).To(HaveOccurred())
+			Expect(resource.ValidateVersion(scheme, "20280229")).To(Succeed())
+		})
+	})
+
+	It("rejects the ambiguous formats", func() {
+		_, err := resource.NewCalVer("YYYYMMDD", now)
+		Expect(err).To(MatchError(ContainSubstring("must separate MM")))
+		_, err = resource.NewCalVer("MM.MICRO", now)
+		Expect(err).To(MatchError(ContainSubstring("must contain the year")))
+		_, err = resource.NewCalVer("YYYY.0Y", now)
+		Expect(err).To(MatchError(ContainSubstring("single placeholder")))
+		_, err = resource.NewCalVer("YYYY.DD", now)
+		Expect(err).To(MatchError(ContainSubstring("must contain the month")))
+	})
+})
@@ -0,0 +1,246 @@
+// Package server exposes the counters as a REST service,
+// so that the systems other than Concourse can allocate the versions without git credentials.
+//
+//	GET  /counters/{name}         returns the current version with ETag
+//	POST /counters/{name}/bump    increments the version by the query n (default 1)
+//	PUT  /counters/{name}         stores {"version": "..."} if If-Match is the current ETag, or "*"
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	resource "github.com/cappyzawa/romver-resource"
+	"github.com/cappyzawa/romver-resource/driver"
+	"github.com/cappyzawa/romver-resource/romver"
+)
+
+// Server serves the counters
+type Server struct {
+	// Counters are the counters by name
+	Counters map[string]romver.Counter
+	// Token is the bearer token required for all requests, empty means no authentication
+	Token string
+	// Redactor masks the secrets of the counters in the error responses
+	Redactor *resource.Redactor
+
+	mu sync.Mutex
+	// locks serialize the operations of each counter, whose driver is not safe for concurrent use
+	locks map[string]*sync.Mutex
+}
+
+// OpenCounters returns the counters of the sources by name.
+// Each counter writes its files such as the clone in its own directory under workDir,
+// so that the counters do not share the clone or the credentials.
+func OpenCounters(sources map[string]resource.Source, workDir string) (map[string]romver.Counter, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counters := map[string]romver.Counter{}
+	for _, name := range names {
+		dir, err := ioutil.TempDir(workDir, "counter")
+		if err != nil {
+			return nil, err
+		}
+		counter, err := romver.FromSourceInDir(sources[name], dir)
+		if err != nil {
+			return nil, fmt.Errorf("opening counter %s: %w", name, err)
+		}
+		counters[name] = counter
+	}
+	return counters, nil
+}
+
+// CounterResponse is the body of the responses for the counter
+type CounterResponse struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// SetRequest is the body of PUT request
+type SetRequest struct {
+	Version string `json:"version"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="romver"`)
+		s.error(w, http.StatusUnauthorized, "authenticating", errors.New("invalid bearer token"))
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "counters" || (len(parts) == 3 && parts[2] != "bump") {
+		s.error(w, http.StatusNotFound, "routing", fmt.Errorf("not found: /%s", path))
+		return
+	}
+	name := parts[1]
+	counter, ok := s.Counters[name]
+	if !ok {
+		s.error(w, http.StatusNotFound, "routing", fmt.Errorf("unknown counter: %s", name))
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.get(w, r, name, counter)
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		s.set(w, r, name, counter)
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		s.bump(w, r, name, counter)
+	default:
+		s.error(w, http.StatusMethodNotAllowed, "routing", fmt.Errorf("method not allowed: %s", r.Method))
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, name string, counter romver.Counter) {
+	lock := s.lock(name)
+	lock.Lock()
+	version, err := counter.Get(r.Context())
+	lock.Unlock()
+	if err != nil {
+		s.driverError(w, "getting version", err)
+		return
+	}
+	s.respond(w, http.StatusOK, name, version)
+}
+
+func (s *Server) bump(w http.ResponseWriter, r *http.Request, name string, counter romver.Counter) {
+	n := 1
+	if q := r.URL.Query().Get("n"); q != "" {
+		var err error
+		if n, err = strconv.Atoi(q); err != nil || n < 1 {
+			s.error(w, http.StatusBadRequest, "parsing request", fmt.Errorf("n must be a positive integer: %q", q))
+			return
+		}
+	}
+
+	lock := s.lock(name)
+	lock.Lock()
+	version, err := counter.Bump(r.Context(), n)
+	lock.Unlock()
+	if err != nil {
+		s.driverError(w, "bumping version", err)
+		return
+	}
+	s.respond(w, http.StatusOK, name, version)
+}
+
+// set stores the version only if the client has seen the current version,
+// which is given by If-Match. It does not prevent the updates made outside of the server.
+func (s *Server) set(w http.ResponseWriter, r *http.Request, name string, counter romver.Counter) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		s.error(w, http.StatusPreconditionRequired, "parsing request", errors.New("If-Match is required, use * to overwrite"))
+		return
+	}
+	var req SetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.error(w, http.StatusBadRequest, "parsing request", err)
+		return
+	}
+
+	lock := s.lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	if ifMatch != "*" {
+		current, err := counter.Get(r.Context())
+		if err != nil {
+			s.driverError(w, "getting version", err)
+			return
+		}
+		if !matchETag(ifMatch, current) {
+			w.Header().Set("ETag", etag(current))
+			s.error(w, http.StatusPreconditionFailed, "setting version", fmt.Errorf("the current version is %s", current))
+			return
+		}
+	}
+	if err := counter.Set(r.Context(), req.Version); err != nil {
+		s.driverError(w, "setting version", err)
+		return
+	}
+	s.respond(w, http.StatusOK, name, req.Version)
+}
+
+// lock returns the lock of the counter
+func (s *Server) lock(name string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks == nil {
+		s.locks = map[string]*sync.Mutex{}
+	}
+	if s.locks[name] == nil {
+		s.locks[name] = &sync.Mutex{}
+	}
+	return s.locks[name]
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+	// the scheme is case-insensitive, and the token without it is rejected
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func (s *Server) respond(w http.ResponseWriter, status int, name, version string) {
+	w.Header().Set("ETag", etag(version))
+	writeJSON(w, status, CounterResponse{Name: name, Version: version})
+}
+
+// driverError responds the failure of the counter with the status for its kind
+func (s *Server) driverError(w http.ResponseWriter, doing string, err error) {
+	status := http.StatusInternalServerError
+	switch driver.KindOf(err) {
+	case driver.KindInvalidVersion, driver.KindInvalidConfig:
+		status = http.StatusBadRequest
+	case driver.KindNotFound, driver.KindVersionNotFound:
+		status = http.StatusNotFound
+	case driver.KindConflict:
+		status = http.StatusConflict
+	case driver.KindAuth, driver.KindNetwork, driver.KindRemoteRejected:
+		status = http.StatusBadGateway
+	}
+	s.error(w, status, doing, err)
+}
+
+func (s *Server) error(w http.ResponseWriter, status int, doing string, err error) {
+	record := driver.NewErrorRecord(doing, err)
+	record.Error = s.Redactor.Redact(record.Error)
+	writeJSON(w, status, record)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func etag(version string) string {
+	return strconv.Quote(version)
+}
+
+func matchETag(ifMatch, version string) bool {
+	for _, t := range strings.Split(ifMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == etag(version) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,17 @@ package resource_test
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
+
+	resource "github.com/cappyzawa/romver-resource"
 )
 
 var bins struct {
@@ -62,7 +67,38 @@ var _ = SynchronizedAfterSuite(func() {
 	gexec.CleanupBuildArtifacts()
 })
 
+// newBareRepo returns the bare repository under dir whose branch has the file with the content
+func newBareRepo(dir, branch, file, content string) string {
+	git := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+	}
+	bare := filepath.Join(dir, "remote.git")
+	seed := filepath.Join(dir, "seed")
+	git("init", "--bare", bare)
+	git("init", seed)
+	Expect(os.MkdirAll(filepath.Dir(filepath.Join(seed, file)), 0755)).To(Succeed())
+	Expect(ioutil.WriteFile(filepath.Join(seed, file), []byte(content), 0644)).To(Succeed())
+	git("-C", seed, "add", file)
+	git("-C", seed, "-c", "user.name=seed", "-c", "user.email=seed@example.com", "commit", "-m", "seed")
+	git("-C", seed, "push", bare, "HEAD:refs/heads/"+branch)
+	return bare
+}
+
+// isolatedEnv returns the environment whose temporary directory and HOME are dir,
+// so that the command neither reuses the clone nor touches the credentials of the other runs
+func isolatedEnv(dir string) []string {
+	return append(os.Environ(), "TMPDIR="+dir, "HOME="+dir)
+}
+
 func TestRomverResource(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "RomverResource Suite")
 }
+
+// newSource returns the source of the values keyed by the keys of source
+func newSource(values map[string]interface{}) resource.Source {
+	source, err := resource.NewSource(values)
+	Expect(err).NotTo(HaveOccurred())
+	return source
+}
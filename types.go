@@ -1,5 +1,7 @@
 package resource
 
+import "encoding/json"
+
 // Driver represents the driver
 // now, git only
 type Driver string
@@ -30,6 +32,13 @@ type InRequest struct {
 // InParams represents the parameters for get step
 type InParams struct {
 	Bump bool `json:"bump"`
+	// Reserve bumps the version in the driver instead of the local files only
+	Reserve bool   `json:"reserve,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	// OutputTemplates are the files written with the version, keyed by the name of the file
+	OutputTemplates map[string]string `json:"output_templates,omitempty"`
+
+	unknownKeys []string
 }
 
 // InResponse represents the response of get step
@@ -46,8 +55,36 @@ type OutRequest struct {
 
 // OutParams represents the parameters for put step
 type OutParams struct {
-	File string `json:"file"`
-	Bump bool   `json:"bump"`
+	File   string `json:"file"`
+	Bump   bool   `json:"bump"`
+	Branch string `json:"branch,omitempty"`
+	// Counters are updated in a single commit, and the one of the source is the version of the put
+	Counters []CounterParams `json:"counters,omitempty"`
+
+	unknownKeys []string
+}
+
+// CounterParams represents the counter updated with the others in put step
+type CounterParams struct {
+	// Path is the file in the backing store, defaults to file of the source
+	Path    string `json:"path,omitempty"`
+	Counter string `json:"counter,omitempty"`
+	File    string `json:"file,omitempty"`
+	Bump    bool   `json:"bump,omitempty"`
+
+	unknownKeys []string
+}
+
+// Label returns the name of the counter in the problems
+func (p CounterParams) Label() string {
+	switch {
+	case p.Path != "" && p.Counter != "":
+		return p.Path + ":" + p.Counter
+	case p.Counter != "":
+		return p.Counter
+	default:
+		return p.Path
+	}
 }
 
 // OutResponse represents the response of put step
@@ -56,20 +93,86 @@ type OutResponse struct {
 	Metadata Metadata `json:"metadata"`
 }
 
-// Source represents the source configuration
+// CommonSourceKeys are the keys of source shared by all drivers.
+// The other keys are decoded by the selected driver.
+var CommonSourceKeys = []string{
+	"driver",
+	"initial_version",
+	"scheme",
+	"calver_format",
+	"error_format",
+	"timeout",
+}
+
+// Source represents the source configuration.
+// It has the keys shared by all drivers, and the others are decoded by the driver.
 type Source struct {
-	Driver Driver `json:"driver"`
+	Driver Driver `json:"driver" romver:"required"`
 
-	InitialVersion string `json:"initial_version"`
+	InitialVersion string `json:"initial_version,omitempty"`
+	Scheme         string `json:"scheme,omitempty" romver:"enum=integer|calver"`
+	CalVerFormat   string `json:"calver_format,omitempty"`
+	ErrorFormat    string `json:"error_format,omitempty" romver:"enum=text|json"`
+	Timeout        string `json:"timeout,omitempty"`
 
-	URI           string `json:"uri"`
-	Branch        string `json:"branch"`
-	PrivateKey    string `json:"private_key"`
-	Username      string `json:"username"`
-	Password      string `json:"password"`
-	File          string `json:"file"`
-	GitUser       string `json:"git_user"`
-	CommitMessage string `json:"commit_message"`
+	raw json.RawMessage
+}
+
+// UnmarshalJSON keeps the raw source so that the driver can decode its own keys
+func (s *Source) UnmarshalJSON(data []byte) error {
+	type plain Source
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		return err
+	}
+	s.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalJSON encodes the common keys along with the keys of the driver in the raw source
+func (s Source) MarshalJSON() ([]byte, error) {
+	type plain Source
+	common, err := json.Marshal(plain(s))
+	if err != nil || s.raw == nil {
+		return common, err
+	}
+
+	var values, commonValues map[string]json.RawMessage
+	if err := json.Unmarshal(s.raw, &values); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(common, &commonValues); err != nil {
+		return nil, err
+	}
+	for _, k := range CommonSourceKeys {
+		delete(values, k)
+	}
+	for k, v := range commonValues {
+		values[k] = v
+	}
+	return json.Marshal(values)
+}
+
+// Raw returns the source as it was decoded.
+// If the source is not decoded from JSON, it is encoded.
+func (s Source) Raw() (json.RawMessage, error) {
+	if s.raw != nil {
+		return s.raw, nil
+	}
+	return json.Marshal(s)
+}
+
+// NewSource returns the source of the values keyed by the keys of source,
+// e.g. "driver" and "uri" for git driver
+func NewSource(values map[string]interface{}) (Source, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return Source{}, err
+	}
+	var source Source
+	if err := json.Unmarshal(raw, &source); err != nil {
+		return Source{}, err
+	}
+	return source, nil
 }
 
 // Version represents the resource version
@@ -80,6 +183,16 @@ type Version struct {
 // Metadata represents the resorce metadata
 type Metadata []MetadataField
 
+// Value returns the value of the field, or empty if it is not found
+func (m Metadata) Value(name string) string {
+	for _, f := range m {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return ""
+}
+
 // MetadataField represents key/value of metadata
 type MetadataField struct {
 	Name  string `json:"name"`
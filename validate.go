@@ -0,0 +1,250 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validatable reports the problems of the configuration
+type Validatable interface {
+	Validate() []string
+}
+
+// ValidationError represents all problems of the configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d problem(s) found:\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// Validate reports the problems of the keys shared by all drivers
+func (s Source) Validate() []string {
+	var problems []string
+	if s.Driver == DriverUnspecified {
+		problems = append(problems, "driver is required")
+	}
+	if s.CalVerFormat != "" && s.Scheme != SchemeCalVer {
+		problems = append(problems, "calver_format requires scheme calver")
+	}
+	scheme, err := s.VersionScheme()
+	switch {
+	case s.Scheme != "" && s.Scheme != SchemeInteger && s.Scheme != SchemeCalVer:
+		problems = append(problems, fmt.Sprintf("scheme must be integer or calver: %q", s.Scheme))
+	case err != nil:
+		problems = append(problems, err.Error())
+	}
+	if s.InitialVersion != "" && scheme != nil {
+		v, err := scheme.Parse(s.InitialVersion)
+		_, integer := scheme.(IntegerScheme)
+		switch {
+		case integer && (err != nil || scheme.Compare(v, scheme.Initial()) < 0):
+			problems = append(problems, fmt.Sprintf("initial_version must be a non-negative integer: %q", s.InitialVersion))
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("initial_version is not valid: %v", err))
+		case scheme.Compare(v, scheme.Initial()) < 0:
+			problems = append(problems, fmt.Sprintf("initial_version must not precede %s: %q", scheme.Format(scheme.Initial()), s.InitialVersion))
+		}
+	}
+	switch s.ErrorFormat {
+	case "", "text", "json":
+	default:
+		problems = append(problems, fmt.Sprintf("error_format must be text or json: %q", s.ErrorFormat))
+	}
+	if s.Timeout != "" {
+		if d, err := time.ParseDuration(s.Timeout); err != nil || d <= 0 {
+			problems = append(problems, fmt.Sprintf("timeout must be a positive duration such as 5m: %q", s.Timeout))
+		}
+	}
+	return problems
+}
+
+// Validate reports the problems of the parameters for get step
+func (p InParams) Validate() []string {
+	problems := unknownKeyProblems(p.unknownKeys)
+	if p.Reserve && !p.Bump {
+		problems = append(problems, "reserve requires bump")
+	}
+	problems = append(problems, outputTemplateProblems(p.OutputTemplates)...)
+	return problems
+}
+
+// Validate reports the problems of the parameters for put step
+func (p OutParams) Validate() []string {
+	problems := unknownKeyProblems(p.unknownKeys)
+	if len(p.Counters) > 0 {
+		if p.File != "" || p.Bump {
+			problems = append(problems, "counters and file/bump are mutually exclusive")
+		}
+		seen := map[[2]string]bool{}
+		for i, c := range p.Counters {
+			for _, problem := range c.Validate() {
+				problems = append(problems, fmt.Sprintf("counters[%d]: %s", i, problem))
+			}
+			target := [2]string{c.Path, c.Counter}
+			if seen[target] {
+				problems = append(problems, fmt.Sprintf("counters[%d]: duplicated counter: %s", i, c.Label()))
+			}
+			seen[target] = true
+		}
+		return problems
+	}
+	if p.File != "" && p.Bump {
+		problems = append(problems, "file and bump are mutually exclusive")
+	}
+	if p.File == "" && !p.Bump {
+		problems = append(problems, "one of file or bump is required")
+	}
+	return problems
+}
+
+// Validate reports the problems of the counter in the parameters for put step
+func (p CounterParams) Validate() []string {
+	problems := unknownKeyProblems(p.unknownKeys)
+	if p.File != "" && p.Bump {
+		problems = append(problems, "file and bump are mutually exclusive")
+	}
+	if p.File == "" && !p.Bump {
+		problems = append(problems, "one of file or bump is required")
+	}
+	return problems
+}
+
+// UnmarshalJSON records the unknown keys to report them on validation
+func (p *InParams) UnmarshalJSON(data []byte) error {
+	type plain InParams
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	var err error
+	p.unknownKeys, err = UnknownKeys(data, p)
+	return err
+}
+
+// UnmarshalJSON records the unknown keys to report them on validation
+func (p *OutParams) UnmarshalJSON(data []byte) error {
+	type plain OutParams
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	var err error
+	p.unknownKeys, err = UnknownKeys(data, p)
+	return err
+}
+
+// UnmarshalJSON records the unknown keys to report them on validation
+func (p *CounterParams) UnmarshalJSON(data []byte) error {
+	type plain CounterParams
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+	var err error
+	p.unknownKeys, err = UnknownKeys(data, p)
+	return err
+}
+
+func unknownKeyProblems(keys []string) []string {
+	var problems []string
+	for _, k := range keys {
+		problems = append(problems, fmt.Sprintf("unknown key: %s", k))
+	}
+	return problems
+}
+
+// MissingRequired returns the problems of the fields tagged with `romver:"required"` which are empty
+func MissingRequired(v interface{}) []string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	var problems []string
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		if !HasTagOption(f, "required") {
+			continue
+		}
+		if rv.Field(i).IsZero() {
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			problems = append(problems, name+" is required")
+		}
+	}
+	return problems
+}
+
+// HasTagOption reports whether the field has the option in `romver` tag
+func HasTagOption(f reflect.StructField, option string) bool {
+	for _, o := range strings.Split(f.Tag.Get("romver"), ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// TagEnum returns the values of `romver:"enum=a|b"` tag
+func TagEnum(f reflect.StructField) []string {
+	for _, o := range strings.Split(f.Tag.Get("romver"), ",") {
+		if strings.HasPrefix(o, "enum=") {
+			return strings.Split(strings.TrimPrefix(o, "enum="), "|")
+		}
+	}
+	return nil
+}
+
+// UnknownKeys returns the sorted keys of the JSON object which v does not have
+func UnknownKeys(data []byte, v interface{}) ([]string, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	for _, k := range JSONKeys(v) {
+		known[k] = true
+	}
+	var unknown []string
+	for k := range keys {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// JSONKeys returns the keys of the struct which encoding/json decodes
+func JSONKeys(v interface{}) []string {
+	return jsonKeys(reflect.TypeOf(v))
+}
+
+func jsonKeys(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			keys = append(keys, jsonKeys(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}